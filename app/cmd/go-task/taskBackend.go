@@ -2,7 +2,7 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,7 +10,10 @@ import (
 	"time"
 
 	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/outbox"
+	"github.com/bartick/go-task/app/reminder"
 	"github.com/bartick/go-task/app/route"
+	"github.com/bartick/go-task/app/webhook"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -18,21 +21,30 @@ import (
 func runTaskAPI() {
 	gin.SetMode(gin.DebugMode)
 
-	fmt.Println(config)
-
 	// Database Init
 	db, err := model.InitDatabases(config.Database)
 	if err != nil {
 		log.Fatal("Database initialization failed", zap.String("err", err.Error()))
 	}
 
+	sinks, err := outbox.NewSinks(db, config.Outbox)
+	if err != nil {
+		log.Fatal("Outbox initialization failed", zap.String("err", err.Error()))
+	}
+
 	// Start HTTP server
-	router := route.AddAPIRouter(db)
+	router := route.AddAPIRouter(db, config)
+
+	// Every request context derives from baseCtx, so cancelling it stops the
+	// database work of requests that outlive the graceful shutdown.
+	baseCtx, stopRequests := context.WithCancel(context.Background())
+	defer stopRequests()
 
 	serverAddr := config.Server.Address + ":" + config.Server.Port
 	srv := &http.Server{
-		Addr:    serverAddr,
-		Handler: router,
+		Addr:        serverAddr,
+		Handler:     router,
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
 	}
 
 	// Start server in a goroutine so that it doesn't block.
@@ -43,6 +55,17 @@ func runTaskAPI() {
 		}
 	}()
 
+	// Reminders are scanned in the background for as long as the server runs.
+	reminders := reminder.NewScheduler(db, config.Reminder, reminder.NewNotifier(config.Reminder, log))
+	reminders.Start(baseCtx)
+
+	// So are the task events in the outbox, and the deliveries queued for
+	// webhooks.
+	events := outbox.NewDispatcher(db, config.Outbox, sinks)
+	events.Start(baseCtx)
+	webhooks := webhook.NewDispatcher(db, config.Webhook)
+	webhooks.Start(baseCtx)
+
 	// Wait for interrupt signal to gracefully shutdown the server.
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -54,7 +77,11 @@ func runTaskAPI() {
 
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Error("Server forced to shutdown:", zap.String("err", err.Error()))
+		stopRequests()
 	}
+	reminders.Stop()
+	events.Stop()
+	webhooks.Stop()
 
 	log.Info("Server exiting")
 }
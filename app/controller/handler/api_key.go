@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// keyOwner resolves the user whose API keys are being managed, which is
+// always the caller, and answers the request itself when there is none.
+func keyOwner(c *gin.Context, db model.DBTX, message string) (*model.User, bool) {
+	user, err := currentUser(c, db)
+	if err == nil {
+		return user, true
+	}
+	if err == errNoCurrentUser || err == sql.ErrNoRows {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": model.ErrMissingCredentials.Error()})
+		return nil, false
+	}
+	log.Error("Failed to get current user", zap.Error(err))
+	serverError(c, message, err)
+	return nil, false
+}
+
+// HandlerGetAPIKeys lists the caller's API keys without their secrets.
+func HandlerGetAPIKeys(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve API keys"})
+		return
+	}
+
+	user, ok := keyOwner(c, db, "Failed to retrieve API keys")
+	if !ok {
+		return
+	}
+
+	keys, err := model.GetAPIKeys(c.Request.Context(), db, user.ID)
+	if err != nil {
+		log.Error("Failed to get API keys", zap.Error(err))
+		serverError(c, "Failed to retrieve API keys", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": keys})
+}
+
+// HandlerCreateAPIKey mints a key for the caller. The secret is in the
+// response and nowhere else.
+func HandlerCreateAPIKey(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	user, ok := keyOwner(c, db, "Failed to create API key")
+	if !ok {
+		return
+	}
+
+	key, err := model.MintAPIKey(c.Request.Context(), db, user.ID, &req)
+	if err != nil {
+		log.Error("Failed to create API key", zap.Error(err))
+		serverError(c, "Failed to create API key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": key})
+}
+
+// HandlerRevokeAPIKey revokes one of the caller's keys.
+func HandlerRevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	user, ok := keyOwner(c, db, "Failed to revoke API key")
+	if !ok {
+		return
+	}
+
+	revoked, err := model.RevokeAPIKey(c.Request.Context(), db, user.ID, keyID)
+	if err != nil {
+		log.Error("Failed to revoke API key", zap.Error(err))
+		serverError(c, "Failed to revoke API key", err)
+		return
+	}
+
+	if revoked == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func HandlerGetCategories(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		return
+	}
+
+	categories, err := model.GetCategories(c.Request.Context(), db, getProjectID(c))
+	if err != nil {
+		log.Error("Failed to get categories", zap.Error(err))
+		serverError(c, "Failed to retrieve categories", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully retrieved categories",
+		"data":    categories,
+	})
+}
+
+func HandlerGetCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve category"})
+		return
+	}
+
+	category, err := model.GetCategoryByID(c.Request.Context(), db, getProjectID(c), categoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		log.Error("Failed to get category", zap.Error(err))
+		serverError(c, "Failed to retrieve category", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
+func HandlerCreateCategory(c *gin.Context) {
+	var req model.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	category, err := model.CreateCategory(c.Request.Context(), db, getProjectID(c), &req)
+	if err != nil {
+		if err == model.ErrCategoryExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to create category", zap.Error(err))
+		serverError(c, "Failed to create category", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": category})
+}
+
+func HandlerRenameCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	var req model.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+		return
+	}
+
+	// Renaming to the current name affects no rows, so look the category up
+	// afterwards instead of trusting the row count.
+	if _, err := model.RenameCategory(c.Request.Context(), db, getProjectID(c), categoryID, &req); err != nil {
+		if err == model.ErrCategoryMove {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == model.ErrCategoryExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to rename category", zap.Error(err))
+		serverError(c, "Failed to update category", err)
+		return
+	}
+
+	category, err := model.GetCategoryByID(c.Request.Context(), db, getProjectID(c), categoryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		log.Error("Failed to get category", zap.Error(err))
+		serverError(c, "Failed to update category", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": category})
+}
+
+func HandlerDeleteCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete category"})
+		return
+	}
+
+	deleted, err := model.DeleteCategory(c.Request.Context(), db, getProjectID(c), categoryID)
+	if err != nil {
+		log.Error("Failed to delete category", zap.Error(err))
+		serverError(c, "Failed to delete category", err)
+		return
+	}
+
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+}
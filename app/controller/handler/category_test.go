@@ -0,0 +1,112 @@
+package handler_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newCategoryRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.GET("/categories", handler.HandlerGetCategories)
+		router.POST("/categories", handler.HandlerCreateCategory)
+		router.GET("/categories/:id", handler.HandlerGetCategory)
+		router.PATCH("/categories/:id", handler.HandlerRenameCategory)
+		router.DELETE("/categories/:id", handler.HandlerDeleteCategory)
+	})
+}
+
+func TestHandlerGetCategories_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectSelect(mockDB, []interface{}{int64(1)}, []model.CategoryWithCount{
+		{Category: model.Category{ID: 1, Name: "Backend", Path: "Backend"}, TaskCount: 2},
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/categories", nil)
+	newCategoryRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"Backend","parent_id":null,"path":"Backend","task_count":2`)
+}
+
+func TestHandlerCreateCategory_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "Infra", (*uint64)(nil), "Infra"}).
+		Return(&mockResult{lastInsertID: 5, rowsAffected: 1}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/categories", strings.NewReader(`{"name":" Infra "}`))
+	req.Header.Set("Content-Type", "application/json")
+	newCategoryRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `{"id":5,"name":"Infra","parent_id":null,"path":"Infra"}`)
+}
+
+func TestHandlerCreateCategory_InvalidName(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/categories", strings.NewReader(`{"name":""}`))
+	req.Header.Set("Content-Type", "application/json")
+	newCategoryRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlerCreateCategory_Conflict(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &mysql.MySQLError{Number: 1062})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/categories", strings.NewReader(`{"name":"Backend"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newCategoryRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "category already exists")
+}
+
+func TestHandlerRenameCategory_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/categories/9", strings.NewReader(`{"name":"Platform"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newCategoryRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerDeleteCategory_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{uint64(1), int64(1)}).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/categories/1", nil)
+	newCategoryRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Category deleted successfully")
+}
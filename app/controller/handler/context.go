@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	null "github.com/mattn/go-nulltype"
+	"go.uber.org/zap"
+)
+
+// getConfig returns the configuration set by middleware.Config, or the zero
+// configuration when none was set.
+func getConfig(c *gin.Context) model.Configuration {
+	if config, ok := c.Get("config"); ok {
+		if config, ok := config.(*model.Configuration); ok && config != nil {
+			return *config
+		}
+	}
+	return model.Configuration{}
+}
+
+// getPrincipal returns who the request authenticated as, or nil when it
+// did not go through middleware.Auth.
+func getPrincipal(c *gin.Context) *model.Principal {
+	if principal, ok := c.Get("principal"); ok {
+		if principal, ok := principal.(*model.Principal); ok {
+			return principal
+		}
+	}
+	return nil
+}
+
+// getProjectID returns the project set by middleware.Project, which every
+// task and category query is scoped to, or 0 when none was set.
+func getProjectID(c *gin.Context) int64 {
+	return c.GetInt64("project_id")
+}
+
+// writeOptions builds the options for a task write. create_category=true in
+// the query string creates an unknown category_name on the fly, and
+// force=true lets a blocked task start or finish anyway.
+func writeOptions(c *gin.Context) model.WriteOptions {
+	createCategory, _ := strconv.ParseBool(c.Query("create_category"))
+	force, _ := strconv.ParseBool(c.Query("force"))
+	actor := c.GetHeader("X-User")
+	if principal := getPrincipal(c); principal != nil {
+		actor = principal.Email
+	}
+	return model.WriteOptions{
+		MaxDepth:       getConfig(c).Task.MaxDepth,
+		CreateCategory: createCategory,
+		Actor:          actor,
+		Force:          force,
+	}
+}
+
+// errNoCurrentUser is returned by currentUser when the request does not say
+// who is making it.
+var errNoCurrentUser = errors.New("the request must be authenticated or name a user in X-User")
+
+// currentUser returns the authenticated user or, on a request that did not
+// go through middleware.Auth, looks up the user named by the X-User header
+// by id or email. It returns errNoCurrentUser when neither is there and
+// sql.ErrNoRows when X-User names nobody.
+func currentUser(c *gin.Context, db model.DBTX) (*model.User, error) {
+	if principal := getPrincipal(c); principal != nil {
+		return &model.User{ID: principal.UserID, Name: principal.Name, Email: principal.Email}, nil
+	}
+
+	ref := c.GetHeader("X-User")
+	if ref == "" {
+		return nil, errNoCurrentUser
+	}
+	return model.FindUser(c.Request.Context(), db, ref)
+}
+
+// authorizeCategory applies the task policy to filing a task under the
+// category_name of a write, "" for none, and answers the request itself when
+// it is refused. The API router authenticates every request first; one that
+// carries no principal is not checked.
+func authorizeCategory(c *gin.Context, db model.DBTX, name null.NullString) bool {
+	principal := getPrincipal(c)
+	if principal == nil {
+		return true
+	}
+
+	path := ""
+	if name.Valid() {
+		clean, err := model.CleanCategoryPath(name.StringValue())
+		if err != nil {
+			// The write itself rejects the name.
+			return true
+		}
+		path = clean
+	}
+
+	err := model.AuthorizeCategory(c.Request.Context(), db, principal, getProjectID(c), path)
+	if err == nil {
+		return true
+	}
+	var forbidden *model.ForbiddenError
+	if errors.As(err, &forbidden) {
+		c.JSON(http.StatusForbidden, gin.H{"error": forbidden.Reason})
+		return false
+	}
+	log.Error("Failed to authorize request", zap.Error(err))
+	serverError(c, "Failed to authorize request", err)
+	return false
+}
+
+// serverError answers a request whose database work failed: 504 when it ran
+// past the query timeout, 500 with message otherwise.
+func serverError(c *gin.Context, message string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Database query timed out"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HandlerGetTaskDependencies lists the tasks a task is blocked by and the
+// tasks it blocks.
+func HandlerGetTaskDependencies(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve dependencies"})
+		return
+	}
+
+	deps, err := model.GetTaskDependencies(c.Request.Context(), db, getProjectID(c), taskID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		log.Error("Failed to get task dependencies", zap.Error(err))
+		serverError(c, "Failed to retrieve dependencies", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deps})
+}
+
+// HandlerAddTaskBlocker records that the task :blocker_id blocks the task
+// :id. Adding it again is not an error.
+func HandlerAddTaskBlocker(c *gin.Context) {
+	taskID, blockerID, ok := dependencyParams(c)
+	if !ok {
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add dependency"})
+		return
+	}
+
+	err := model.AddTaskDependency(c.Request.Context(), db, getProjectID(c), taskID, blockerID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if err == model.ErrDependencyCycle {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to add task dependency", zap.Error(err))
+		serverError(c, "Failed to add dependency", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dependency added successfully"})
+}
+
+// HandlerRemoveTaskBlocker drops the dependency of the task :id on the task
+// :blocker_id.
+func HandlerRemoveTaskBlocker(c *gin.Context) {
+	taskID, blockerID, ok := dependencyParams(c)
+	if !ok {
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove dependency"})
+		return
+	}
+
+	removed, err := model.RemoveTaskDependency(c.Request.Context(), db, getProjectID(c), taskID, blockerID)
+	if err != nil {
+		log.Error("Failed to remove task dependency", zap.Error(err))
+		serverError(c, "Failed to remove dependency", err)
+		return
+	}
+
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dependency not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dependency removed successfully"})
+}
+
+func dependencyParams(c *gin.Context) (int64, int64, bool) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return 0, 0, false
+	}
+	blockerID, err := strconv.ParseInt(c.Param("blocker_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid blocker task ID"})
+		return 0, 0, false
+	}
+	return taskID, blockerID, true
+}
@@ -0,0 +1,84 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newDependencyRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.GET("/tasks/:id/dependencies", handler.HandlerGetTaskDependencies)
+		router.PUT("/tasks/:id/blockers/:blocker_id", handler.HandlerAddTaskBlocker)
+		router.DELETE("/tasks/:id/blockers/:blocker_id", handler.HandlerRemoveTaskBlocker)
+	})
+}
+
+func TestHandlerAddTaskBlocker_Cycle(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The project is locked, then both tasks, then task 4 turns out to
+	// block task 5 already.
+	expectProjectLock(mockDB)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+		Return(nil).Once()
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5), int64(1)}).
+		Return(nil).Once()
+	expectSelect(mockDB, []interface{}{int64(4)}, []int64{5})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/tasks/4/blockers/5", nil)
+	newDependencyRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "dependency would make a task wait on itself")
+}
+
+func TestHandlerAddTaskBlocker_Self(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/tasks/4/blockers/4", nil)
+	newDependencyRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestHandlerGetTaskDependencies_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+		Return(nil)
+	expectSelect(mockDB, []interface{}{int64(4)}, []model.DependencyTask{{ID: 5, Title: "Configurer JWT", Status: model.StatusTodo}})
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4)}).
+		Return(nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/4/dependencies", nil)
+	newDependencyRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"blocked_by":[{"id":5,"title":"Configurer JWT","status":"todo"}],"blocks":[]`)
+}
+
+func TestHandlerRemoveTaskBlocker_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(4), int64(9), int64(1)}).
+		Return(&mockResult{}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/4/blockers/9", nil)
+	newDependencyRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
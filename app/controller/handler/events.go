@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// streamPoll is how often a stream looks for new events.
+	streamPoll = time.Second
+	// streamHeartbeat is how often an idle stream sends a comment, so that
+	// proxies do not close it.
+	streamHeartbeat = 15 * time.Second
+	// streamGrace is how long a stream keeps looking for an event that shows
+	// up after events with higher IDs.
+	streamGrace = 10 * time.Second
+	// streamReset tells a resuming client that events it missed are gone,
+	// so it has to reload what it follows.
+	streamReset = "reset"
+)
+
+// outboxHead is the highest outbox ID seen at some point in time.
+type outboxHead struct {
+	at time.Time
+	id int64
+}
+
+// eventCursor tracks where a stream is in the outbox. An event gets its ID
+// when it is written but can only be read once its transaction commits, so
+// it may show up after events with higher IDs. The cursor reads again
+// everything written in the last streamGrace, and skips what it already
+// sent.
+type eventCursor struct {
+	filter *model.EventFilter
+	after  int64
+	sent   map[int64]bool
+	heads  []outboxHead
+}
+
+func (cur *eventCursor) next(ctx context.Context, db model.DBTX, now time.Time) ([]model.OutboxEvent, error) {
+	head, err := model.GetOutboxHead(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	events, err := model.GetStreamEvents(ctx, db, cur.filter, cur.after, model.MaxStreamEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	var fresh []model.OutboxEvent
+	for _, event := range events {
+		if !cur.sent[event.ID] {
+			cur.sent[event.ID] = true
+			fresh = append(fresh, event)
+		}
+	}
+
+	// Whatever had its ID by streamGrace ago is taken as settled, as far as
+	// this read got.
+	cur.heads = append(cur.heads, outboxHead{at: now, id: head})
+	settled := cur.after
+	for len(cur.heads) > 0 && now.Sub(cur.heads[0].at) >= streamGrace {
+		settled = max(settled, cur.heads[0].id)
+		cur.heads = cur.heads[1:]
+	}
+	if len(events) == model.MaxStreamEvents {
+		settled = min(settled, events[len(events)-1].ID)
+	}
+	if settled > cur.after {
+		cur.after = settled
+		for id := range cur.sent {
+			if id <= settled {
+				delete(cur.sent, id)
+			}
+		}
+	}
+	return fresh, nil
+}
+
+// lastEventID is where a reconnecting client left off, from the
+// Last-Event-ID header or, for clients that cannot set it, the
+// last_event_id query parameter.
+func lastEventID(c *gin.Context) (int64, bool, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 63)
+	if err != nil {
+		return 0, false, err
+	}
+	return int64(id), true, nil
+}
+
+// HandlerStreamEvents streams the task events of the project as
+// Server-Sent Events, as they are written. Each event has its outbox ID,
+// which a client resumes from with Last-Event-ID. Without it, the stream
+// starts with the next event.
+func HandlerStreamEvents(c *gin.Context) {
+	filter := model.EventFilter{
+		ProjectID: getProjectID(c),
+		Category:  strings.TrimSpace(c.Query("category")),
+	}
+	var err error
+	if filter.RootID, err = queryInt(c, "root"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	after, resume, err := lastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stream events"})
+		return
+	}
+
+	// The stream itself is not bound by the query timeout, but every read
+	// of it is.
+	ctx := c.Request.Context()
+	timeout := getConfig(c).Database.QueryTimeout
+	withTimeout := func() (context.Context, context.CancelFunc) {
+		if timeout <= 0 {
+			return context.WithCancel(ctx)
+		}
+		return context.WithTimeout(ctx, timeout)
+	}
+
+	// A resumed stream reads again the events written in the streamGrace
+	// before Last-Event-ID, as one of them may have committed after it, and
+	// skips only that one. When the outbox no longer keeps it, the client is
+	// told to reset and the stream goes on from the next event.
+	sent := map[int64]bool{}
+	readCtx, cancel := withTimeout()
+	if resume {
+		var from int64
+		if from, err = model.GetResumePoint(readCtx, db, after, streamGrace); err == nil {
+			sent[after] = true
+			after = from
+		}
+	}
+	reset := err == model.ErrEventNotKept
+	if !resume || reset {
+		after, err = model.GetOutboxHead(readCtx, db)
+	}
+	cancel()
+	if err != nil {
+		log.Error("Failed to start event stream", zap.Error(err))
+		serverError(c, "Failed to stream events", err)
+		return
+	}
+	cursor := &eventCursor{filter: &filter, after: after, sent: sent}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if reset {
+		event := sse.Event{Event: streamReset, Data: gin.H{"error": model.ErrEventNotKept.Error()}}
+		if after > 0 {
+			event.Id = strconv.FormatInt(after, 10)
+		}
+		c.Render(-1, event)
+	}
+	c.Writer.Flush()
+
+	poll := time.NewTicker(streamPoll)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		readCtx, cancel := withTimeout()
+		events, err := cursor.next(readCtx, db, time.Now())
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Error("Failed to read event stream", zap.Error(err))
+			}
+			return
+		}
+
+		for _, event := range events {
+			c.Render(-1, sse.Event{
+				Id:    strconv.FormatInt(event.ID, 10),
+				Event: string(event.Event),
+				Data:  event,
+			})
+		}
+		if len(events) > 0 {
+			lastWrite = time.Now()
+			c.Writer.Flush()
+		} else if time.Since(lastWrite) >= streamHeartbeat {
+			_, _ = c.Writer.WriteString(": heartbeat\n\n")
+			lastWrite = time.Now()
+			c.Writer.Flush()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newEventsRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.GET("/projects/:pid/events", handler.HandlerStreamEvents)
+	})
+}
+
+func expectOutboxHead(mockDB *model.MockDBTX, head int64) {
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*int64) = head
+			return nil
+		}).Once()
+}
+
+func expectStreamEvents(mockDB *model.MockDBTX, after int64, ids ...int64) {
+	events := []model.OutboxEvent{}
+	for _, id := range ids {
+		events = append(events, model.OutboxEvent{
+			ID:        id,
+			ProjectID: 1,
+			TaskID:    4,
+			Event:     model.EventTaskUpdated,
+			Payload:   model.RawJSON(`{"task_id":4}`),
+		})
+	}
+	expectSelect(mockDB, []interface{}{after, int64(1), 100}, events)
+}
+
+// expectResumePoint answers the lookup of where a stream resuming after
+// event 5 reads from.
+func expectResumePoint(mockDB *model.MockDBTX, from int64) {
+	written := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	expectGet(mockDB, []interface{}{int64(5)}, written)
+	expectGet(mockDB, []interface{}{int64(5), written.Add(-10 * time.Second)}, from)
+}
+
+func TestHandlerStreamEvents_Resume(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Event 4 committed after event 5 was sent, and event 7 commits after
+	// event 8 was sent. The stream reads again from event 3, written 10s
+	// before event 5, so it finds both, and sends nothing twice.
+	expectResumePoint(mockDB, 3)
+	expectOutboxHead(mockDB, 8)
+	expectStreamEvents(mockDB, 3, 4, 5, 6, 8)
+	expectOutboxHead(mockDB, 9)
+	expectStreamEvents(mockDB, 3, 4, 5, 6, 7, 8)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/projects/1/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	newEventsRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream"))
+	body := w.Body.String()
+	assert.Equal(t, 4, strings.Count(body, "event:task.updated\n"))
+	assert.Contains(t, body, "id:4\n")
+	assert.NotContains(t, body, "id:5\n")
+	assert.Equal(t, 1, strings.Count(body, "id:8\n"))
+	assert.Less(t, strings.Index(body, "id:8\n"), strings.Index(body, "id:7\n"))
+	assert.Contains(t, body, `"payload":{"task_id":4}`)
+}
+
+func TestHandlerStreamEvents_Reset(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Event 5 is past the outbox retention, so the client is told to reset
+	// and the stream goes on from the head.
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5)}).
+		Return(sql.ErrNoRows).Once()
+	expectOutboxHead(mockDB, 40)
+	expectOutboxHead(mockDB, 40)
+	expectStreamEvents(mockDB, 40)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/projects/1/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	newEventsRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.True(t, strings.HasPrefix(body, "id:40\nevent:reset\n"))
+	assert.Contains(t, body, "no longer kept")
+}
+
+func TestHandlerStreamEvents_Invalid(t *testing.T) {
+	for _, target := range []string{
+		"/projects/1/events?root=top",
+		"/projects/1/events?last_event_id=-1",
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", target, nil)
+		newEventsRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, target)
+	}
+}
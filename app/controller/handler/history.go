@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HandlerGetTaskHistory lists a task's change events. It accepts
+// field=status to keep only events touching that field and since/until to
+// bound the time range. History stays readable after a task is deleted.
+func HandlerGetTaskHistory(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	filter := &model.HistoryFilter{Field: c.Query("field")}
+	if filter.Field != "" && !model.IsTrackedField(filter.Field) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid field %q", filter.Field)})
+		return
+	}
+	if filter.Since, err = queryTime(c, "since"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Until, err = queryTime(c, "until"); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task history"})
+		return
+	}
+
+	events, err := model.GetTaskHistory(c.Request.Context(), db, getProjectID(c), taskID, filter)
+	if err != nil {
+		log.Error("Failed to get task history", zap.Error(err))
+		serverError(c, "Failed to retrieve task history", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": events})
+}
@@ -0,0 +1,59 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-nulltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandlerGetTaskHistory_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]model.TaskEvent) = []model.TaskEvent{
+				{
+					ID:      1,
+					TaskID:  1,
+					Action:  model.ActionUpdate,
+					Actor:   nulltype.NullStringOf("alice"),
+					Changes: model.Changes{"status": {From: "todo", To: "in_progress"}},
+				},
+			}
+			return nil
+		})
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.GET("/tasks/:id/history", handler.HandlerGetTaskHistory)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/1/history?field=status", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"actor":"alice"`)
+	assert.Contains(t, w.Body.String(), `"status":{"from":"todo","to":"in_progress"}`)
+}
+
+func TestHandlerGetTaskHistory_InvalidField(t *testing.T) {
+	router := newTestRouter(model.NewMockDBTX(t), func(router *gin.Engine) {
+		router.GET("/tasks/:id/history", handler.HandlerGetTaskHistory)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/1/history?field=updated_at", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `invalid field`)
+}
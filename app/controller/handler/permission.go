@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HandlerGetCategoryPermissions lists the roles granted on a category.
+func HandlerGetCategoryPermissions(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve permissions"})
+		return
+	}
+
+	permissions, err := model.GetCategoryPermissions(c.Request.Context(), db, getProjectID(c), categoryID)
+	if err != nil {
+		log.Error("Failed to get category permissions", zap.Error(err))
+		serverError(c, "Failed to retrieve permissions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": permissions})
+}
+
+// HandlerSetCategoryPermission grants a user a role on a category and its
+// subcategories.
+func HandlerSetCategoryPermission(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Role model.Role `json:"role"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": model.ErrInvalidRole.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set permission"})
+		return
+	}
+
+	permission, err := model.SetCategoryPermission(c.Request.Context(), db, getProjectID(c), categoryID, userID, req.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+			return
+		}
+		if err == model.ErrUnknownUser {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Error("Failed to set category permission", zap.Error(err))
+		serverError(c, "Failed to set permission", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": permission})
+}
+
+// HandlerDeleteCategoryPermission takes back a user's role on a category.
+func HandlerDeleteCategoryPermission(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete permission"})
+		return
+	}
+
+	deleted, err := model.DeleteCategoryPermission(c.Request.Context(), db, getProjectID(c), categoryID, userID)
+	if err != nil {
+		log.Error("Failed to delete category permission", zap.Error(err))
+		serverError(c, "Failed to delete permission", err)
+		return
+	}
+
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Permission not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Permission deleted successfully"})
+}
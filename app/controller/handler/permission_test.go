@@ -0,0 +1,109 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/route/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// newPolicyRouter serves a few guarded routes as the given principal, the
+// way the API router does after authentication.
+func newPolicyRouter(db model.DBTX, principal *model.Principal) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.Use(func(c *gin.Context) {
+			c.Set("principal", principal)
+		})
+		admin := middleware.RequireRole(model.RoleAdmin)
+		router.POST("/tasks", handler.HandlerCreateTasks)
+		router.DELETE("/tasks/:id", middleware.AuthorizeTask(model.AccessDeleteTask), handler.HandlerDeleteTask)
+		router.POST("/categories", admin, handler.HandlerCreateCategory)
+		router.PUT("/categories/:id/permissions/:user_id", admin, handler.HandlerSetCategoryPermission)
+	})
+}
+
+func TestPolicy_DeleteSubtreeForbidden(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			assert.Contains(t, query, "AS has_subtasks")
+			dest.(*struct {
+				CategoryPath *string `db:"category_path"`
+				HasSubtasks  bool    `db:"has_subtasks"`
+			}).HasSubtasks = true
+			return nil
+		})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/tasks/1", nil)
+	newPolicyRouter(mockDB, &model.Principal{UserID: 2, Role: model.RoleMember}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "only admins can delete a task that has subtasks")
+}
+
+func TestPolicy_ViewerCannotCreate(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(3), int64(1), "Backend"}).
+		Return(nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"New Task","category_name":" Backend "}`))
+	req.Header.Set("Content-Type", "application/json")
+	newPolicyRouter(mockDB, &model.Principal{UserID: 3, Role: model.RoleViewer}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `the viewer role cannot change tasks in category \"Backend\"`)
+}
+
+func TestPolicy_AdminOnly(t *testing.T) {
+	router := newPolicyRouter(model.NewMockDBTX(t), &model.Principal{UserID: 2, Role: model.RoleMember})
+
+	for _, r := range []struct{ method, path, body string }{
+		{"POST", "/categories", `{"name":"Infra"}`},
+		{"PUT", "/categories/1/permissions/3", `{"role":"member"}`},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(r.method, r.path, strings.NewReader(r.body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code, r.path)
+		assert.Contains(t, w.Body.String(), "this requires the admin role, you are member")
+	}
+}
+
+func TestHandlerSetCategoryPermission_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), uint64(2)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			dest.(*model.CategoryWithCount).Path = "Frontend"
+			return nil
+		})
+	expectGet(mockDB, []interface{}{int64(3)}, 1)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(3), uint64(2), model.RoleMember}).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/categories/2/permissions/3", strings.NewReader(`{"role":"member"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newPolicyRouter(mockDB, &model.Principal{UserID: 1, Role: model.RoleAdmin}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":3,"category_id":2,"category_path":"Frontend","role":"member"`)
+}
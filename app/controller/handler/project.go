@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HandlerGetProjects lists the projects the caller is a member of, or every
+// project for an admin.
+func HandlerGetProjects(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve projects"})
+		return
+	}
+
+	projects, err := model.GetProjects(c.Request.Context(), db, getPrincipal(c))
+	if err != nil {
+		log.Error("Failed to get projects", zap.Error(err))
+		serverError(c, "Failed to retrieve projects", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully retrieved projects",
+		"data":    projects,
+	})
+}
+
+// HandlerGetProject returns the project loaded by middleware.Project.
+func HandlerGetProject(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project"})
+		return
+	}
+
+	project, err := model.GetProjectByID(c.Request.Context(), db, getProjectID(c))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		log.Error("Failed to get project", zap.Error(err))
+		serverError(c, "Failed to retrieve project", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": project})
+}
+
+func HandlerCreateProject(c *gin.Context) {
+	var req model.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		return
+	}
+
+	project, err := model.CreateProject(c.Request.Context(), db, &req)
+	if err != nil {
+		if err == model.ErrProjectExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to create project", zap.Error(err))
+		serverError(c, "Failed to create project", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": project})
+}
+
+func HandlerRenameProject(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("pid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	var req model.ProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename project"})
+		return
+	}
+
+	project, err := model.RenameProject(c.Request.Context(), db, projectID, &req)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		if err == model.ErrProjectExists {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to rename project", zap.Error(err))
+		serverError(c, "Failed to rename project", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": project})
+}
+
+// HandlerDeleteProject deletes a project together with its categories,
+// tasks and task history.
+func HandlerDeleteProject(c *gin.Context) {
+	projectID, err := strconv.ParseInt(c.Param("pid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		return
+	}
+
+	deleted, err := model.DeleteProject(c.Request.Context(), db, projectID)
+	if err != nil {
+		log.Error("Failed to delete project", zap.Error(err))
+		serverError(c, "Failed to delete project", err)
+		return
+	}
+
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted successfully"})
+}
+
+// HandlerGetProjectMembers lists the users let into the project.
+func HandlerGetProjectMembers(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve members"})
+		return
+	}
+
+	members, err := model.GetProjectMembers(c.Request.Context(), db, getProjectID(c))
+	if err != nil {
+		log.Error("Failed to get project members", zap.Error(err))
+		serverError(c, "Failed to retrieve members", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// HandlerAddProjectMember lets a user into the project.
+func HandlerAddProjectMember(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add member"})
+		return
+	}
+
+	if err := model.AddProjectMember(c.Request.Context(), db, getProjectID(c), userID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			return
+		}
+		if err == model.ErrUnknownUser {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		log.Error("Failed to add project member", zap.Error(err))
+		serverError(c, "Failed to add member", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member added successfully"})
+}
+
+// HandlerRemoveProjectMember shuts a user out of the project. Admins keep
+// their way in regardless.
+func HandlerRemoveProjectMember(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	removed, err := model.RemoveProjectMember(c.Request.Context(), db, getProjectID(c), userID)
+	if err != nil {
+		log.Error("Failed to remove project member", zap.Error(err))
+		serverError(c, "Failed to remove member", err)
+		return
+	}
+
+	if removed == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
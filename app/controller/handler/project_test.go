@@ -0,0 +1,146 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/route/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// projectMember is a member of project 1 only.
+var projectMember = &model.Principal{UserID: 5, Role: model.RoleMember}
+
+func newProjectRouter(db model.DBTX) *gin.Engine {
+	return newProjectRouterAs(db, projectMember)
+}
+
+// newProjectRouterAs serves the project routes as the given principal.
+func newProjectRouterAs(db model.DBTX, principal *model.Principal) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.Use(func(c *gin.Context) {
+			c.Set("principal", principal)
+		})
+		router.POST("/projects", handler.HandlerCreateProject)
+		router.PATCH("/projects/:pid", handler.HandlerRenameProject)
+		project := router.Group("/projects/:pid", middleware.Project())
+		project.GET("", handler.HandlerGetProject)
+		project.GET("/trash", handler.HandlerGetTrash)
+	})
+}
+
+func TestHandlerCreateProject_Conflict(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{"Platform"}).
+		Return(nil, &mysql.MySQLError{Number: 1062})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/projects", strings.NewReader(`{"name":" Platform "}`))
+	req.Header.Set("Content-Type", "application/json")
+	newProjectRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "a project with this name already exists")
+}
+
+func TestHandlerRenameProject_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{"Web", int64(9)}).
+		Return(&mockResult{}, nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(9)}).
+		Return(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/projects/9", strings.NewReader(`{"name":"Web"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newProjectRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestProjectScope(t *testing.T) {
+	t.Run("unknown project", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(7)}).
+			Return(sql.ErrNoRows)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/projects/7/trash", nil)
+		newProjectRouterAs(mockDB, &model.Principal{UserID: 1, Role: model.RoleAdmin}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), "Project not found")
+	})
+
+	t.Run("other project", func(t *testing.T) {
+		// A member of project 1 finds project 2 just as missing as an
+		// unknown one, for the project itself and for what is in it.
+		for _, target := range []string{"/projects/2", "/projects/2/trash"} {
+			mockDB := model.NewMockDBTX(t)
+			mockDB.EXPECT().
+				GetContext(mock.Anything, mock.Anything, mock.MatchedBy(func(query string) bool {
+					return strings.Contains(query, "project_members")
+				}), []interface{}{int64(2), int64(5)}).
+				Return(sql.ErrNoRows)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", target, nil)
+			newProjectRouter(mockDB).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusNotFound, w.Code, target)
+			assert.Contains(t, w.Body.String(), "Project not found")
+		}
+	})
+
+	t.Run("no principal", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/projects/1/trash", nil)
+		newTestRouter(model.NewMockDBTX(t), func(router *gin.Engine) {
+			router.GET("/projects/:pid/trash", middleware.Project(), handler.HandlerGetTrash)
+		}).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/projects/abc/trash", nil)
+		newProjectRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("scoped", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(5)}).
+			Return(nil)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+			RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+				assert.Contains(t, query, "t.project_id = ?")
+				return nil
+			})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/projects/1/trash", nil)
+		newProjectRouter(mockDB).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
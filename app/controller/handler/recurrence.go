@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const defaultOccurrences = 5
+
+// HandlerGetTaskOccurrences previews the due dates of the next occurrences
+// of a recurring task, count of them (5 by default).
+func HandlerGetTaskOccurrences(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	count, err := queryInt(c, "count")
+	if err == nil && count.Valid() && (count.Int64Value() < 1 || count.Int64Value() > model.MaxOccurrences) {
+		err = fmt.Errorf("count must be between 1 and %d", model.MaxOccurrences)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	n := defaultOccurrences
+	if count.Valid() {
+		n = int(count.Int64Value())
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to preview occurrences"})
+		return
+	}
+
+	dates, err := model.GetTaskOccurrences(c.Request.Context(), db, getProjectID(c), taskID, n)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		log.Error("Failed to preview task occurrences", zap.Error(err))
+		serverError(c, "Failed to preview occurrences", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": dates})
+}
@@ -0,0 +1,60 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-nulltype"
+	"github.com/stretchr/testify/assert"
+)
+
+func newOccurrencesRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.GET("/tasks/:id/occurrences", handler.HandlerGetTaskOccurrences)
+	})
+}
+
+func TestHandlerGetTaskOccurrences(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	expectGet(mockDB, []interface{}{int64(8), int64(1)}, model.Task{
+		ID:         8,
+		DueDate:    nulltype.NullTimeOf(time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)),
+		Recurrence: nulltype.NullStringOf("FREQ=MONTHLY;BYMONTHDAY=-1"),
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/8/occurrences?count=2", nil)
+	newOccurrencesRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": ["2026-03-31T00:00:00Z", "2026-04-30T00:00:00Z"]}`, w.Body.String())
+}
+
+func TestHandlerGetTaskOccurrences_InvalidCount(t *testing.T) {
+	for _, count := range []string{"0", "101", "many"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks/8/occurrences?count="+count, nil)
+		newOccurrencesRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, count)
+	}
+}
+
+func TestHandlerCreateTasks_InvalidRecurrence(t *testing.T) {
+	router := newTestRouter(model.NewMockDBTX(t), func(router *gin.Engine) {
+		router.POST("/tasks", handler.HandlerCreateTasks)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(`{"title": "Chore", "recurrence": "FREQ=HOURLY"}`))
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unsupported FREQ HOURLY")
+}
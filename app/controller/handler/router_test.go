@@ -0,0 +1,58 @@
+package handler_test
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
+)
+
+// newTestRouter builds a router whose requests see db as their database and
+// project 1 as their project, as the middleware would set them, and lets
+// routes register what is under test. routes can add middleware of its own
+// before its handlers.
+func newTestRouter(db model.DBTX, routes func(router *gin.Engine)) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("db", db)
+		c.Set("project_id", int64(1))
+	})
+	routes(router)
+	return router
+}
+
+// expectProjectLock answers the lock that changes to the task tree or the
+// dependencies of project 1 take first.
+func expectProjectLock(mockDB *model.MockDBTX) {
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, "FROM projects") && strings.HasSuffix(query, "FOR UPDATE")
+		}), []interface{}{int64(1)}).
+		Return(nil).Once()
+}
+
+// expectGet answers one GetContext with args by filling in value.
+func expectGet[T any](mockDB *model.MockDBTX, args interface{}, value T) *model.MockDBTX_GetContext_Call {
+	call := mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, args).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*T) = value
+			return nil
+		})
+	call.Once()
+	return call
+}
+
+// expectSelect answers one SelectContext with args by filling in rows.
+func expectSelect[T any](mockDB *model.MockDBTX, args interface{}, rows []T) *model.MockDBTX_SelectContext_Call {
+	call := mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, args).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]T) = rows
+			return nil
+		})
+	call.Once()
+	return call
+}
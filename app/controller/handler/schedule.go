@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// scheduleStart reads start from the query string, defaulting to now.
+func scheduleStart(c *gin.Context) (time.Time, bool) {
+	start, err := queryTime(c, "start")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return time.Time{}, false
+	}
+	if !start.Valid() {
+		return time.Now().UTC().Truncate(time.Second), true
+	}
+	return start.TimeValue(), true
+}
+
+// HandlerGetSchedule computes the critical path over every task of the
+// project.
+func HandlerGetSchedule(c *gin.Context) {
+	start, ok := scheduleStart(c)
+	if !ok {
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute schedule"})
+		return
+	}
+
+	schedule, err := model.GetProjectSchedule(c.Request.Context(), db, getProjectID(c), start)
+	if err != nil {
+		if err == model.ErrScheduleCycle {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to compute schedule", zap.Error(err))
+		serverError(c, "Failed to compute schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
+
+// HandlerGetTaskSchedule computes the critical path over a task and its
+// subtasks.
+func HandlerGetTaskSchedule(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	start, ok := scheduleStart(c)
+	if !ok {
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute schedule"})
+		return
+	}
+
+	schedule, err := model.GetTaskSchedule(c.Request.Context(), db, getProjectID(c), taskID, start)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if err == model.ErrScheduleCycle {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to compute task schedule", zap.Error(err))
+		serverError(c, "Failed to compute schedule", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedule})
+}
@@ -0,0 +1,77 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-nulltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newScheduleRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.GET("/schedule", handler.HandlerGetSchedule)
+		router.GET("/tasks/:id/schedule", handler.HandlerGetTaskSchedule)
+	})
+}
+
+func TestHandlerGetSchedule_Start(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Return(nil).Twice()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/schedule?start=2026-03-02", nil)
+	newScheduleRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"start":"2026-03-02T00:00:00Z"`)
+	assert.Contains(t, w.Body.String(), `"critical_path":[]`)
+}
+
+func TestHandlerGetSchedule_InvalidStart(t *testing.T) {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/schedule?start=monday", nil)
+	newScheduleRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlerGetTaskSchedule_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(9), int64(1)}).
+		Return(nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/9/schedule", nil)
+	newScheduleRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerGetTaskSchedule_Cycle(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			parent := model.TaskHierarchy{Task: model.Task{ID: 1}}
+			sub := model.TaskHierarchy{Task: model.Task{ID: 2, ParentTaskID: nulltype.NullInt64Of(1)}}
+			*dest.(*[]model.TaskHierarchy) = []model.TaskHierarchy{parent, sub}
+			return nil
+		})
+	expectSelect(mockDB, []interface{}{int64(1)}, []model.TaskDependency{{BlockerID: 1, BlockedID: 2}})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/1/schedule", nil)
+	newScheduleRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
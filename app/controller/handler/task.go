@@ -2,15 +2,23 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/bartick/go-task/app/model"
 	"github.com/gin-gonic/gin"
+	null "github.com/mattn/go-nulltype"
 	"go.uber.org/zap"
 )
 
 func HandlerGetTasks(c *gin.Context) {
+	filter, err := parseTaskFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	db, ok := c.MustGet("db").(model.DBTX)
 	if !ok {
 		log.Error("Failed to get database connection")
@@ -18,16 +26,50 @@ func HandlerGetTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := model.GetAllTasks(db)
+	if c.Query("assignee") == "me" {
+		user, err := currentUser(c, db)
+		if err != nil {
+			if err == errNoCurrentUser || err == sql.ErrNoRows {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "assignee=me: " + errNoCurrentUser.Error()})
+				return
+			}
+			log.Error("Failed to get current user", zap.Error(err))
+			serverError(c, "Failed to retrieve tasks", err)
+			return
+		}
+		filter.AssigneeID = null.NullInt64Of(user.ID)
+	}
+
+	page, err := model.GetTaskPage(c.Request.Context(), db, filter)
 	if err != nil {
 		log.Error("Failed to get tasks: " + err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		serverError(c, "Failed to retrieve tasks", err)
+		return
+	}
+
+	total, err := model.CountTasks(c.Request.Context(), db, filter)
+	if err != nil {
+		log.Error("Failed to count tasks", zap.Error(err))
+		serverError(c, "Failed to retrieve tasks", err)
 		return
 	}
 
+	meta := gin.H{
+		"total":  total,
+		"limit":  filter.PageSize(),
+		"offset": filter.Offset,
+	}
+	if page.NextCursor != "" {
+		meta["next_cursor"] = page.NextCursor
+	}
+	if page.PrevCursor != "" {
+		meta["prev_cursor"] = page.PrevCursor
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Successfully retrieved tasks",
-		"data":    tasks,
+		"data":    page.Tasks,
+		"meta":    meta,
 	})
 }
 
@@ -45,14 +87,14 @@ func HandlerGetTask(c *gin.Context) {
 		return
 	}
 
-	task, err := model.GetByID(db, taskID)
+	task, err := model.GetByID(c.Request.Context(), db, getProjectID(c), taskID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 			return
 		}
 		log.Error("Failed to get task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task"})
+		serverError(c, "Failed to retrieve task", err)
 		return
 	}
 
@@ -73,14 +115,14 @@ func HandlerGetSubTasks(c *gin.Context) {
 		return
 	}
 
-	task, err := model.GetTaskWithSubtasks(db, taskID)
+	task, err := model.GetTaskWithSubtasks(c.Request.Context(), db, getProjectID(c), taskID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 			return
 		}
 		log.Error("Failed to get task subtasks", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve task subtasks"})
+		serverError(c, "Failed to retrieve task subtasks", err)
 		return
 	}
 
@@ -94,6 +136,21 @@ func HandlerCreateTasks(c *gin.Context) {
 		return
 	}
 
+	if req.Status != nil && !req.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": model.ErrInvalidStatus.Error()})
+		return
+	}
+	if !model.ValidEstimate(req.EstimateHours) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": model.ErrInvalidEstimate.Error()})
+		return
+	}
+	if req.Recurrence.Valid() {
+		if _, err := model.ParseRecurrence(req.Recurrence.StringValue()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	db, ok := c.MustGet("db").(model.DBTX)
 	if !ok {
 		log.Error("Failed to get database connection")
@@ -101,14 +158,47 @@ func HandlerCreateTasks(c *gin.Context) {
 		return
 	}
 
-	task, err := model.CreateTask(db, &req)
+	if !authorizeCategory(c, db, req.CategoryName) {
+		return
+	}
+
+	// The caller reports the task unless the body names someone else. An
+	// X-User that is not a registered user still works as a plain actor name.
+	if !req.ReporterID.Valid() {
+		user, err := currentUser(c, db)
+		if err != nil && err != errNoCurrentUser && err != sql.ErrNoRows {
+			log.Error("Failed to get current user", zap.Error(err))
+			serverError(c, "Failed to create task", err)
+			return
+		}
+		if user != nil {
+			req.ReporterID = null.NullInt64Of(user.ID)
+		}
+	}
+
+	task, err := model.CreateTask(c.Request.Context(), db, getProjectID(c), &req, writeOptions(c))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if status, ok := parentErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if categoryError(c, err) {
+			return
+		}
+		if err == model.ErrInvalidTag || err == model.ErrTooManyTags {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == model.ErrUnknownUser {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		log.Error("Failed to create task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		serverError(c, "Failed to create task", err)
 		return
 	}
 
@@ -128,9 +218,12 @@ func HandlerUpdateTask(c *gin.Context) {
 		return
 	}
 
-	// check if everything is nil
-	if !req.Title.Valid() && !req.Description.Valid() && !req.Status.Valid() && !req.Priority.Valid() &&
-		!req.DueDate.Valid() && !req.CompletedAt.Valid() && !req.ParentTaskID.Valid() && !req.CategoryName.Valid() {
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.IsEmpty() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
 		return
 	}
@@ -142,14 +235,47 @@ func HandlerUpdateTask(c *gin.Context) {
 		return
 	}
 
-	effected, err := model.UpdateTask(db, taskID, &req)
+	// middleware.AuthorizeTask has checked the task where it is; moving it
+	// to another category needs the same rights there.
+	if req.CategoryName.Valid() || req.Cleared["category_name"] {
+		if !authorizeCategory(c, db, req.CategoryName) {
+			return
+		}
+	}
+
+	effected, err := model.UpdateTask(c.Request.Context(), db, getProjectID(c), taskID, &req, writeOptions(c))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Unable to update Task"})
 			return
 		}
+		if status, ok := parentErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		var transition *model.TransitionError
+		if errors.As(err, &transition) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		var blocked *model.BlockedError
+		if errors.As(err, &blocked) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "blocked_by": blocked.BlockedBy})
+			return
+		}
+		if categoryError(c, err) {
+			return
+		}
+		if err == model.ErrInvalidTag || err == model.ErrTooManyTags {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err == model.ErrUnknownUser {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
 		log.Error("Failed to update task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		serverError(c, "Failed to update task", err)
 		return
 	}
 
@@ -161,6 +287,48 @@ func HandlerUpdateTask(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Task updated successfully"})
 }
 
+func HandlerMoveTask(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	var req model.MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move task"})
+		return
+	}
+
+	task, err := model.MoveTask(c.Request.Context(), db, getProjectID(c), taskID, &req, writeOptions(c))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			return
+		}
+		if err == model.ErrInvalidPosition {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if status, ok := parentErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to move task", zap.Error(err))
+		serverError(c, "Failed to move task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": task})
+}
+
 func HandlerDeleteTask(c *gin.Context) {
 	taskID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
@@ -175,14 +343,14 @@ func HandlerDeleteTask(c *gin.Context) {
 		return
 	}
 
-	effected, err := model.DeleteTask(db, taskID)
+	effected, err := model.DeleteTask(c.Request.Context(), db, getProjectID(c), taskID, writeOptions(c))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
 			return
 		}
 		log.Error("Failed to delete task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		serverError(c, "Failed to delete task", err)
 		return
 	}
 
@@ -193,3 +361,36 @@ func HandlerDeleteTask(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
 }
+
+// categoryError answers a task write whose category_name was rejected and
+// reports whether it did: unknown names get a 422 listing them with the
+// closest existing categories.
+func categoryError(c *gin.Context, err error) bool {
+	var unknown *model.UnknownCategoryError
+	if errors.As(err, &unknown) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":              err.Error(),
+			"unknown_categories": unknown.Names,
+			"suggestions":        unknown.Suggestions,
+		})
+		return true
+	}
+	if err == model.ErrInvalidCategoryName {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return true
+	}
+	return false
+}
+
+// parentErrorStatus maps a rejected parent link to its HTTP status: a cycle
+// conflicts with the current tree, anything else is an unusable request.
+func parentErrorStatus(err error) (int, bool) {
+	var parentErr *model.ParentError
+	if !errors.As(err, &parentErr) {
+		return 0, false
+	}
+	if errors.Is(err, model.ErrTaskCycle) {
+		return http.StatusConflict, true
+	}
+	return http.StatusUnprocessableEntity, true
+}
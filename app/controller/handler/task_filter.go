@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	null "github.com/mattn/go-nulltype"
+)
+
+// parseTaskFilter reads the GET /tasks query string:
+//
+//	status=todo,in_progress  category=Backend/Auth,Bug  include_subcategories=true
+//	tags_any=q3,tech-debt  tags_all=customer-facing  tags_none=blocked
+//	priority_min=1  priority_max=3
+//	due_after=2025-08-01  due_before=2025-08-31T23:59:59Z
+//	parent_id=1 | parent_id=root
+//	assignee=7 | assignee=me | assignee=none
+//	completed=true|false
+//	sort=-priority,created_at  limit=20  offset=40 | cursor=<next_cursor>
+func parseTaskFilter(c *gin.Context) (*model.TaskFilter, error) {
+	var (
+		filter = &model.TaskFilter{ProjectID: getProjectID(c)}
+		err    error
+	)
+
+	for _, s := range splitList(c.Query("status")) {
+		status := model.TaskStatus(s)
+		if !status.IsValid() {
+			return nil, fmt.Errorf("invalid status %q", s)
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+	filter.Categories = splitList(c.Query("category"))
+	if raw, ok := c.GetQuery("include_subcategories"); ok {
+		if filter.Subcategories, err = strconv.ParseBool(raw); err != nil {
+			return nil, fmt.Errorf("invalid include_subcategories %q", raw)
+		}
+	}
+
+	if filter.TagsAny, err = queryTags(c, "tags_any"); err != nil {
+		return nil, err
+	}
+	if filter.TagsAll, err = queryTags(c, "tags_all"); err != nil {
+		return nil, err
+	}
+	if filter.TagsNone, err = queryTags(c, "tags_none"); err != nil {
+		return nil, err
+	}
+
+	if filter.PriorityMin, err = queryInt(c, "priority_min"); err != nil {
+		return nil, err
+	}
+	if filter.PriorityMax, err = queryInt(c, "priority_max"); err != nil {
+		return nil, err
+	}
+	if filter.DueAfter, err = queryTime(c, "due_after"); err != nil {
+		return nil, err
+	}
+	if filter.DueBefore, err = queryTime(c, "due_before"); err != nil {
+		return nil, err
+	}
+
+	if parent := c.Query("parent_id"); parent == "root" {
+		filter.RootOnly = true
+	} else if filter.ParentTaskID, err = queryInt(c, "parent_id"); err != nil {
+		return nil, err
+	}
+
+	// assignee=me needs the database to know who is asking and is resolved
+	// by the handler.
+	switch c.Query("assignee") {
+	case "none":
+		filter.Unassigned = true
+	case "me":
+	default:
+		if filter.AssigneeID, err = queryInt(c, "assignee"); err != nil {
+			return nil, err
+		}
+	}
+
+	if raw, ok := c.GetQuery("completed"); ok {
+		completed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid completed %q", raw)
+		}
+		filter.Completed = &completed
+	}
+
+	if filter.Sort, err = model.ParseSort(c.Query("sort")); err != nil {
+		return nil, err
+	}
+
+	limit, err := queryInt(c, "limit")
+	if err != nil {
+		return nil, err
+	}
+	offset, err := queryInt(c, "offset")
+	if err != nil {
+		return nil, err
+	}
+	if offset.Int64Value() < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset.Int64Value())
+	}
+	filter.Limit = int(limit.Int64Value())
+	filter.Offset = int(offset.Int64Value())
+
+	if token := c.Query("cursor"); token != "" {
+		if filter.Cursor, err = model.DecodeCursor(token); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// queryTags reads a comma separated tag list, cleaned the way tags are
+// stored.
+func queryTags(c *gin.Context, key string) ([]string, error) {
+	tags, err := model.CleanTags(splitList(c.Query(key)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return tags, nil
+}
+
+func queryInt(c *gin.Context, key string) (null.NullInt64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return null.NullInt64{}, nil
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return null.NullInt64{}, fmt.Errorf("invalid %s %q", key, raw)
+	}
+	return null.NullInt64Of(n), nil
+}
+
+// queryTime accepts either a full RFC 3339 timestamp or a plain date.
+func queryTime(c *gin.Context, key string) (null.NullTime, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return null.NullTime{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return null.NullTimeOf(t), nil
+		}
+	}
+	return null.NullTime{}, fmt.Errorf("invalid %s %q", key, raw)
+}
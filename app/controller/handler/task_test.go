@@ -1,14 +1,17 @@
 package handler_test
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bartick/go-task/app/controller/handler"
 	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/route/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/mattn/go-nulltype"
 	"github.com/stretchr/testify/assert"
@@ -27,8 +30,8 @@ func TestHandlerGetTask_Success(t *testing.T) {
 
 	// Expect GetByID to be called inside the handler
 	mockDB.EXPECT().
-		Get(mock.Anything, mock.Anything, mock.Anything).
-		RunAndReturn(func(dest interface{}, query string, args ...interface{}) error {
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 			// dest is a pointer to a Task
 			taskPtr := dest.(*model.Task)
 			*taskPtr = *dummyTask
@@ -39,6 +42,7 @@ func TestHandlerGetTask_Success(t *testing.T) {
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.GET("/tasks/:id", handler.HandlerGetTask)
 
@@ -58,13 +62,14 @@ func TestHandlerGetTask_Failure(t *testing.T) {
 
 	// Expect GetByID to be called and return an error
 	mockDB.EXPECT().
-		Get(mock.Anything, mock.Anything, mock.Anything).
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(sql.ErrNoRows)
 
 	// Create router with the mock DB
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.GET("/tasks/:id", handler.HandlerGetTask)
 
@@ -78,6 +83,30 @@ func TestHandlerGetTask_Failure(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Task not found")
 }
 
+func TestHandlerGetTask_Timeout(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The query is cut off by the request deadline
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.Use(middleware.QueryTimeout(time.Millisecond))
+		router.GET("/tasks/:id", handler.HandlerGetTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks/1", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Contains(t, w.Body.String(), "Database query timed out")
+}
+
 func TestHandlerGetTask_InvalidID(t *testing.T) {
 	// Create router without DB since it won't be used
 	router := gin.New()
@@ -105,17 +134,26 @@ func TestHandlerGetTasks_Success(t *testing.T) {
 
 	// Expect Select to be called inside the handler
 	mockDB.EXPECT().
-		Select(mock.Anything, mock.Anything, mock.Anything).
-		RunAndReturn(func(dest interface{}, query string, args ...interface{}) error {
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 			tasksPtr := dest.(*[]model.TaskWithCategory) // <- important
 			*tasksPtr = dummyTasks
 			return nil
 		})
 
+	// Expect Get to be called for the total count
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*int64) = 2
+			return nil
+		})
+
 	// Create router with the mock DB
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.GET("/tasks", handler.HandlerGetTasks)
 
@@ -128,6 +166,23 @@ func TestHandlerGetTasks_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), `"title":"Task 1"`)
 	assert.Contains(t, w.Body.String(), `"title":"Task 2"`)
+	assert.Contains(t, w.Body.String(), `"meta":{"limit":50,"offset":0,"total":2}`)
+}
+
+func TestHandlerGetTasks_InvalidQuery(t *testing.T) {
+	router := gin.New()
+	router.GET("/tasks", handler.HandlerGetTasks)
+
+	for _, query := range []string{
+		"status=blocked", "sort=description", "priority_min=high", "due_before=tomorrow", "offset=-1", "cursor=not-a-cursor",
+		"tags_any=" + strings.Repeat("x", 51),
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/tasks?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
 }
 
 func TestHandlerGetTasks_Failure(t *testing.T) {
@@ -136,13 +191,14 @@ func TestHandlerGetTasks_Failure(t *testing.T) {
 
 	// Expect Select to be called and return an error
 	mockDB.EXPECT().
-		Select(mock.Anything, mock.Anything, mock.Anything).
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(sql.ErrConnDone)
 
 	// Create router with the mock DB
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.GET("/tasks", handler.HandlerGetTasks)
 
@@ -175,8 +231,8 @@ func TestHandlerGetSubTasks_Success(t *testing.T) {
 
 	// Expect Select to be called inside the handler
 	mockDB.EXPECT().
-		Select(mock.Anything, mock.Anything, mock.Anything).
-		RunAndReturn(func(dest interface{}, query string, args ...interface{}) error {
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 			subTasksPtr := dest.(*[]model.TaskHierarchy) // must match
 			*subTasksPtr = dummySubTasks
 			return nil
@@ -186,6 +242,7 @@ func TestHandlerGetSubTasks_Success(t *testing.T) {
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.GET("/tasks/:id/subtasks", handler.HandlerGetSubTasks)
 
@@ -206,13 +263,14 @@ func TestHandlerGetSubTasks_Failure(t *testing.T) {
 
 	// Expect Select to be called and return an error
 	mockDB.EXPECT().
-		Select(mock.Anything, mock.Anything, mock.Anything).
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(sql.ErrConnDone)
 
 	// Create router with the mock DB
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.GET("/tasks/:id/subtasks", handler.HandlerGetSubTasks)
 
@@ -252,22 +310,28 @@ func TestHandlerCreateTasks_Success(t *testing.T) {
 
 	// Expect NamedExec (because your INSERT uses named parameters)
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
 		Return(&mockResult{lastInsertID: 1, rowsAffected: 1}, nil)
 
 	// Expect Get to fetch the created task
 	mockDB.EXPECT().
-		Get(mock.Anything, mock.Anything, mock.Anything).
-		RunAndReturn(func(dest interface{}, query string, args ...interface{}) error {
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 			taskPtr := dest.(*model.Task)
 			*taskPtr = *createdTask
 			return nil
 		})
 
+	// Expect Exec to record the creation in the task history
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{lastInsertID: 1}, nil)
+
 	// Create router with the mock DB
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.POST("/tasks", handler.HandlerCreateTasks)
 
@@ -302,15 +366,19 @@ func TestHandlerUpdateTask_Success(t *testing.T) {
 	// Initialize mock DB
 	mockDB := model.NewMockDBTX(t)
 
-	// Expect only NamedExec (Update)
+	// Expect Get to read the task around the update, and NamedExec (Update)
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
 		Return(&mockResult{rowsAffected: 1}, nil)
 
 	// Create router with the mock DB
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB) // Inject mock DB into context
+		c.Set("project_id", int64(1))
 	})
 	router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
 
@@ -328,6 +396,115 @@ func TestHandlerUpdateTask_Success(t *testing.T) {
 	assert.Contains(t, w.Body.String(), `"Task updated successfully"`)
 }
 
+func TestHandlerUpdateTask_StatusTransition(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The task is locked and read first to validate the transition
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			dest.(*model.Task).Status = model.StatusInProgress
+			return nil
+		})
+	// Nothing blocks it
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"status":"done"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandlerUpdateTask_Blocked(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*model.Task) = model.Task{ID: 4, Status: model.StatusTodo}
+			return nil
+		})
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]int64) = []int64{5}
+			return nil
+		})
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/4", strings.NewReader(`{"status":"in_progress"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"blocked_by":[5]`)
+}
+
+func TestHandlerUpdateTask_IllegalTransition(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			dest.(*model.Task).Status = model.StatusTodo
+			return nil
+		})
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"status":"done"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"cannot move task from todo to done"`)
+}
+
+func TestHandlerUpdateTask_InvalidStatus(t *testing.T) {
+	router := gin.New()
+	router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"status":"blocked"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"invalid status"`)
+}
+
+func TestHandlerUpdateTask_ClearCompletedAt(t *testing.T) {
+	router := gin.New()
+	router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"completed_at":null}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"completed_at cannot be null, it is cleared by moving the task out of done"`)
+}
+
 func TestHandlerUpdateTask_InvalidID(t *testing.T) {
 	router := gin.New()
 	router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
@@ -369,17 +546,116 @@ func TestHandlerUpdateTask_NoFields(t *testing.T) {
 	assert.Contains(t, w.Body.String(), `"No fields to update"`)
 }
 
-func TestHandlerUpdateTask_NotFound(t *testing.T) {
+func TestHandlerUpdateTask_ClearField(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	// An explicit null is an update on its own
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"due_date":null}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandlerUpdateTask_NullTitle(t *testing.T) {
+	router := gin.New()
+	router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"title":null}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"title cannot be null"`)
+}
+
+func TestHandlerUpdateTask_ParentCycle(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	expectProjectLock(mockDB)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"parent_task_id":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "task cannot be its own ancestor")
+}
+
+func TestHandlerUpdateTask_ParentNotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The parent cannot be read, so it does not exist
+	expectProjectLock(mockDB)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(42), int64(1)}).
+		Return(sql.ErrNoRows)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"parent_task_id":42}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "parent task not found")
+}
+
+func TestHandlerUpdateTask_Unchanged(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
-	// Update executes but affects 0 rows
+	// The task exists but already has this title, so no row changes
+	expectGet(mockDB, []interface{}{int64(1), int64(1)}, model.Task{ID: 1, Title: "Same Task"})
+	expectGet(mockDB, []interface{}{int64(1), int64(1)}, model.Task{ID: 1, Title: "Same Task"})
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
 		Return(&mockResult{rowsAffected: 0}, nil)
 
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/tasks/1", strings.NewReader(`{"title":"Same Task"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Task updated successfully"`)
+}
+
+func TestHandlerUpdateTask_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The task cannot be read, so nothing is updated
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(sql.ErrNoRows)
+
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB)
+		c.Set("project_id", int64(1))
 	})
 	router.PATCH("/tasks/:id", handler.HandlerUpdateTask)
 
@@ -396,14 +672,19 @@ func TestHandlerUpdateTask_NotFound(t *testing.T) {
 func TestHandlerDeleteTask_Success(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
-	// Expect delete to affect 1 row
+	// Expect delete to affect 1 row, then deleted_at to be read back for
+	// the history entry
 	mockDB.EXPECT().
-		Exec(mock.Anything, mock.Anything, mock.Anything).
+		ExecContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(&mockResult{rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB)
+		c.Set("project_id", int64(1))
 	})
 	router.DELETE("/tasks/:id", handler.HandlerDeleteTask)
 
@@ -432,12 +713,13 @@ func TestHandlerDeleteTask_NotFound(t *testing.T) {
 
 	// Exec returns 0 rows affected
 	mockDB.EXPECT().
-		Exec(mock.Anything, mock.Anything, mock.Anything).
+		ExecContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(&mockResult{rowsAffected: 0}, nil)
 
 	router := gin.New()
 	router.Use(func(c *gin.Context) {
 		c.Set("db", mockDB)
+		c.Set("project_id", int64(1))
 	})
 	router.DELETE("/tasks/:id", handler.HandlerDeleteTask)
 
@@ -448,3 +730,113 @@ func TestHandlerDeleteTask_NotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	assert.Contains(t, w.Body.String(), `"Task not found"`)
 }
+
+func TestHandlerMoveTask_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Task 3 stays under task 1, which is top-level, and moves to the front
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			if task, ok := dest.(*model.Task); ok {
+				*task = model.Task{ID: 3, Title: "Subtask", ParentTaskID: nulltype.NullInt64Of(1)}
+			}
+			return nil
+		})
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]int64) = []int64{2}
+			return nil
+		})
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.POST("/tasks/:id/move", handler.HandlerMoveTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/3/move", strings.NewReader(`{"position":0}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Subtask"`)
+}
+
+func TestHandlerMoveTask_InvalidPosition(t *testing.T) {
+	router := newTestRouter(model.NewMockDBTX(t), func(router *gin.Engine) {
+		router.POST("/tasks/:id/move", handler.HandlerMoveTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/3/move", strings.NewReader(`{"parent_task_id":null,"position":-2}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "position must not be negative")
+}
+
+func TestHandlerMoveTask_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectProjectLock(mockDB)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(3), int64(1)}).
+		Return(sql.ErrNoRows)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.POST("/tasks/:id/move", handler.HandlerMoveTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/3/move", strings.NewReader(`{"parent_task_id":null}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerCreateTasks_UnknownCategory(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), "Bakend"}).
+		Return(nil)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]string) = []string{"Backend", "Bug"}
+			return nil
+		})
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.POST("/tasks", handler.HandlerCreateTasks)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"New Task","category_name":"Bakend"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"unknown_categories":["Bakend"]`)
+	assert.Contains(t, w.Body.String(), `"suggestions":{"Bakend":["Backend"]}`)
+}
+
+func TestHandlerCreateTasks_InvalidTag(t *testing.T) {
+	router := newTestRouter(model.NewMockDBTX(t), func(router *gin.Engine) {
+		router.POST("/tasks", handler.HandlerCreateTasks)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks", strings.NewReader(`{"title":"New Task","tags":["q3","a,b"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), model.ErrInvalidTag.Error())
+}
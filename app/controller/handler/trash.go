@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func HandlerGetTrash(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash"})
+		return
+	}
+
+	tasks, err := model.GetTrash(c.Request.Context(), db, getProjectID(c))
+	if err != nil {
+		log.Error("Failed to get trash", zap.Error(err))
+		serverError(c, "Failed to retrieve trash", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Successfully retrieved trash",
+		"data":    tasks,
+	})
+}
+
+func HandlerRestoreTask(c *gin.Context) {
+	taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore task"})
+		return
+	}
+
+	restored, err := model.RestoreTask(c.Request.Context(), db, getProjectID(c), taskID, writeOptions(c))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found in trash"})
+			return
+		}
+		if err == model.ErrParentTrashed {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		log.Error("Failed to restore task", zap.Error(err))
+		serverError(c, "Failed to restore task", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Task restored successfully",
+		"restored": restored,
+	})
+}
+
+func HandlerPurgeTrash(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge trash"})
+		return
+	}
+
+	retention := getConfig(c).Task.TrashRetention
+	purged, err := model.PurgeTrash(c.Request.Context(), db, getProjectID(c), retention)
+	if err != nil {
+		log.Error("Failed to purge trash", zap.Error(err))
+		serverError(c, "Failed to purge trash", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Trash purged successfully",
+		"purged":    purged,
+		"retention": retention.String(),
+	})
+}
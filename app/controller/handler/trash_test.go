@@ -0,0 +1,79 @@
+package handler_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-nulltype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandlerGetTrash_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectSelect(mockDB, []interface{}{int64(1)}, []model.TaskWithCategory{
+		{Task: model.Task{ID: 4, Title: "Deleted Task", DeletedAt: nulltype.NullTimeOf(time.Now())}},
+	})
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.GET("/trash", handler.HandlerGetTrash)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/trash", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Deleted Task"`)
+}
+
+func TestHandlerRestoreTask_NotInTrash(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectProjectLock(mockDB)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(sql.ErrNoRows)
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.POST("/tasks/:id/restore", handler.HandlerRestoreTask)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/tasks/4/restore", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Task not found in trash")
+}
+
+func TestHandlerPurgeTrash_UsesRetention(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), int64(3600), int64(1), int64(3600)}).
+		Return(&mockResult{rowsAffected: 2}, nil)
+
+	config := &model.Configuration{Task: model.TaskConfig{TrashRetention: time.Hour}}
+
+	router := newTestRouter(mockDB, func(router *gin.Engine) {
+		router.Use(func(c *gin.Context) {
+			c.Set("config", config)
+		})
+		router.DELETE("/trash", handler.HandlerPurgeTrash)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/trash", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"purged":2`)
+}
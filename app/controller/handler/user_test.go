@@ -0,0 +1,148 @@
+package handler_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newUserRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.GET("/users", handler.HandlerGetUsers)
+		router.POST("/users", handler.HandlerCreateUser)
+		router.GET("/users/:id", handler.HandlerGetUser)
+		router.PATCH("/users/:id", handler.HandlerUpdateUser)
+		router.DELETE("/users/:id", handler.HandlerDeleteUser)
+		router.GET("/tasks", handler.HandlerGetTasks)
+	})
+}
+
+// expectUser answers the lookup of one user by id or email.
+func expectUser(mockDB *model.MockDBTX, ref interface{}, user model.User) {
+	expectGet(mockDB, []interface{}{ref}, user)
+}
+
+func TestHandlerCreateUser_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{"Alice", "alice@example.com", model.RoleMember}).
+		Return(&mockResult{lastInsertID: 3, rowsAffected: 1}, nil)
+	expectUser(mockDB, int64(3), model.User{ID: 3, Name: "Alice", Email: "alice@example.com"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"Alice","email":"Alice@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":3,"name":"Alice","email":"alice@example.com"`)
+}
+
+func TestHandlerCreateUser_Invalid(t *testing.T) {
+	for _, body := range []string{`{"name":"Alice"}`, `{"name":"Alice","email":"alice"}`, `{"name":"","email":"alice@example.com"}`} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		newUserRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestHandlerCreateUser_Conflict(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/users", strings.NewReader(`{"name":"Alice","email":"alice@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestHandlerGetUser_Me(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectUser(mockDB, "alice@example.com", model.User{ID: 3, Name: "Alice", Email: "alice@example.com"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users/me", nil)
+	req.Header.Set("X-User", "alice@example.com")
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":3`)
+
+	// Without the header nobody is asking.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users/me", nil)
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlerUpdateUser_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, "UPDATE users SET name = ? WHERE id = ?", []interface{}{"Bob", int64(9)}).
+		Return(&mockResult{}, nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(9)}).
+		Return(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/users/9", strings.NewReader(`{"name":"Bob"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerGetTasks_AssigneeMe(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectUser(mockDB, int64(3), model.User{ID: 3})
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(3), model.DefaultTaskLimit + 1, 0}).
+		Return(nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(3)}).
+		Return(nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?assignee=me", nil)
+	req.Header.Set("X-User", "3")
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandlerGetTasks_AssigneeMeUnknown(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{"nobody@example.com"}).
+		Return(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/tasks?assignee=me", nil)
+	req.Header.Set("X-User", "nobody@example.com")
+	newUserRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// recentDeliveries is how many deliveries of a webhook are listed.
+const recentDeliveries = 50
+
+// HandlerGetWebhooks lists the project's webhooks without their secrets.
+func HandlerGetWebhooks(c *gin.Context) {
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve webhooks"})
+		return
+	}
+
+	webhooks, err := model.GetWebhooks(c.Request.Context(), db, getProjectID(c))
+	if err != nil {
+		log.Error("Failed to get webhooks", zap.Error(err))
+		serverError(c, "Failed to retrieve webhooks", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": webhooks})
+}
+
+// HandlerCreateWebhook subscribes a URL to the project's task events. The
+// secret deliveries are signed with is in the response and nowhere else.
+func HandlerCreateWebhook(c *gin.Context) {
+	var req model.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create webhook"})
+		return
+	}
+
+	webhook, err := model.CreateWebhook(c.Request.Context(), db, getProjectID(c), &req)
+	if err != nil {
+		log.Error("Failed to create webhook", zap.Error(err))
+		serverError(c, "Failed to create webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": webhook})
+}
+
+// HandlerDeleteWebhook unsubscribes a webhook. Deliveries still pending are
+// dropped.
+func HandlerDeleteWebhook(c *gin.Context) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete webhook"})
+		return
+	}
+
+	deleted, err := model.DeleteWebhook(c.Request.Context(), db, getProjectID(c), webhookID)
+	if err != nil {
+		log.Error("Failed to delete webhook", zap.Error(err))
+		serverError(c, "Failed to delete webhook", err)
+		return
+	}
+
+	if deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// HandlerGetWebhookDeliveries lists the latest deliveries of a webhook,
+// newest first.
+func HandlerGetWebhookDeliveries(c *gin.Context) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve deliveries"})
+		return
+	}
+
+	deliveries, err := model.GetWebhookDeliveries(c.Request.Context(), db, getProjectID(c), webhookID, recentDeliveries)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+			return
+		}
+		log.Error("Failed to get webhook deliveries", zap.Error(err))
+		serverError(c, "Failed to retrieve deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+// HandlerReplayWebhookDelivery queues the payload of an earlier delivery
+// again. The new delivery goes out with the next dispatch.
+func HandlerReplayWebhookDelivery(c *gin.Context) {
+	webhookID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+
+	db, ok := c.MustGet("db").(model.DBTX)
+	if !ok {
+		log.Error("Failed to get database connection")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replay delivery"})
+		return
+	}
+
+	delivery, err := model.ReplayWebhookDelivery(c.Request.Context(), db, getProjectID(c), webhookID, deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+			return
+		}
+		log.Error("Failed to replay webhook delivery", zap.Error(err))
+		serverError(c, "Failed to replay delivery", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"data": delivery})
+}
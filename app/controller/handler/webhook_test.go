@@ -0,0 +1,85 @@
+package handler_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/controller/handler"
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newWebhookRouter(db model.DBTX) *gin.Engine {
+	return newTestRouter(db, func(router *gin.Engine) {
+		router.POST("/webhooks", handler.HandlerCreateWebhook)
+		router.GET("/webhooks/:id/deliveries", handler.HandlerGetWebhookDeliveries)
+		router.POST("/webhooks/:id/deliveries/:delivery_id/replay", handler.HandlerReplayWebhookDelivery)
+	})
+}
+
+func TestHandlerCreateWebhook_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{lastInsertID: 3, rowsAffected: 1}, nil)
+	expectGet(mockDB, []interface{}{int64(3), int64(1)}, model.Webhook{ID: 3, ProjectID: 1, URL: "https://example.com/hooks"})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/webhooks", strings.NewReader(`{"url":"https://example.com/hooks","secret":"a-shared-secret-of-ours"}`))
+	req.Header.Set("Content-Type", "application/json")
+	newWebhookRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"a-shared-secret-of-ours"`)
+}
+
+func TestHandlerCreateWebhook_Invalid(t *testing.T) {
+	for _, body := range []string{
+		`{"url":"mailto:ops@example.com"}`,
+		`{"url":"https://example.com","events":["task.archived"]}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/webhooks", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		newWebhookRouter(model.NewMockDBTX(t)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+	}
+}
+
+func TestHandlerGetWebhookDeliveries_NotFound(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(9), int64(1)}).
+		Return(sql.ErrNoRows)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/webhooks/9/deliveries", nil)
+	newWebhookRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandlerReplayWebhookDelivery(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(7), int64(3), int64(1)}).
+		Return(&mockResult{lastInsertID: 12, rowsAffected: 1}, nil)
+	expectGet(mockDB, []interface{}{int64(12)}, model.WebhookDelivery{
+		ID:      12,
+		Status:  model.DeliveryPending,
+		Payload: model.RawJSON(`{"event":"task.deleted"}`),
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/webhooks/3/deliveries/7/replay", nil)
+	newWebhookRouter(mockDB).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), `"payload":{"event":"task.deleted"}`)
+}
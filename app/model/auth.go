@@ -0,0 +1,330 @@
+package model
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+const (
+	// APIKeyPrefix starts every API key, which is how a bearer token is told
+	// apart from a JWT.
+	APIKeyPrefix = "gtk_"
+	// apiKeyBytes of randomness make a key; its SHA-256 is all that is
+	// stored.
+	apiKeyBytes = 24
+	// apiKeyShownPrefix characters of a key are kept in clear so that the
+	// owner can tell their keys apart.
+	apiKeyShownPrefix = 12
+	maxAPIKeyName     = 100
+
+	// jwtLeeway absorbs clock skew between the token issuer and us.
+	jwtLeeway = 30 * time.Second
+)
+
+var (
+	ErrMissingCredentials = errors.New("authentication required")
+	ErrInvalidCredentials = errors.New("invalid or expired credentials")
+	ErrInvalidAPIKeyName  = errors.New("api key names must be 1 to 100 characters")
+)
+
+// Principal is who an authenticated request acts as.
+type Principal struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   Role   `json:"role"`
+	// Method is how the request authenticated, "api_key" or "jwt".
+	Method string `json:"method"`
+	// APIKeyID is the key that was used when Method is "api_key".
+	APIKeyID int64 `json:"api_key_id,omitempty"`
+}
+
+// APIKey describes a key without its secret. Key is only filled in when the
+// key has just been minted; it cannot be recovered later.
+type APIKey struct {
+	ID        int64         `json:"id" db:"id"`
+	UserID    int64         `json:"user_id" db:"user_id"`
+	Name      string        `json:"name" db:"name"`
+	Prefix    string        `json:"prefix" db:"prefix"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	ExpiresAt null.NullTime `json:"expires_at" db:"expires_at"`
+	RevokedAt null.NullTime `json:"revoked_at" db:"revoked_at"`
+	Key       string        `json:"key,omitempty" db:"-"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string        `json:"name"`
+	ExpiresAt null.NullTime `json:"expires_at"`
+}
+
+func (r *CreateAPIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || utf8.RuneCountInString(r.Name) > maxAPIKeyName {
+		return ErrInvalidAPIKeyName
+	}
+	if r.ExpiresAt.Valid() && !r.ExpiresAt.TimeValue().After(time.Now()) {
+		return errors.New("expires_at must be in the future")
+	}
+	return nil
+}
+
+const (
+	queryCreateAPIKey = `
+	INSERT INTO api_keys (user_id, name, prefix, key_hash, expires_at)
+	VALUES (?, ?, ?, ?, ?)
+	`
+
+	queryGetAPIKey = `
+		SELECT id, user_id, name, prefix, created_at, expires_at, revoked_at
+		FROM api_keys
+		WHERE id = ?
+	`
+
+	queryGetAPIKeys = `
+		SELECT id, user_id, name, prefix, created_at, expires_at, revoked_at
+		FROM api_keys
+		WHERE user_id = ?
+		ORDER BY id
+	`
+
+	queryRevokeAPIKey = `
+	UPDATE api_keys SET revoked_at = NOW()
+	WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`
+
+	queryAuthenticateAPIKey = `
+		SELECT k.id AS api_key_id, u.id AS user_id, u.name, u.email, u.role
+		FROM api_keys k
+		INNER JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = ?
+			AND k.revoked_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > NOW())
+	`
+)
+
+// hashAPIKey is how keys are stored and looked up. Keys are long random
+// strings, so a plain SHA-256 is enough to keep a database leak from
+// handing out working keys.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// MintAPIKey creates a key for the user and returns it with its secret.
+func MintAPIKey(ctx context.Context, db DBTX, userID int64, req *CreateAPIKeyRequest) (*APIKey, error) {
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	result, err := db.ExecContext(ctx, queryCreateAPIKey, userID, req.Name, key[:apiKeyShownPrefix], hashAPIKey(key), req.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var apiKey APIKey
+	if err := db.GetContext(ctx, &apiKey, queryGetAPIKey, id); err != nil {
+		return nil, err
+	}
+	apiKey.Key = key
+	return &apiKey, nil
+}
+
+// GetAPIKeys lists a user's keys, revoked ones included.
+func GetAPIKeys(ctx context.Context, db DBTX, userID int64) ([]APIKey, error) {
+	keys := []APIKey{}
+	err := db.SelectContext(ctx, &keys, queryGetAPIKeys, userID)
+	return keys, err
+}
+
+// RevokeAPIKey stops one of the user's keys from working. It returns 0 when
+// the user has no such live key.
+func RevokeAPIKey(ctx context.Context, db DBTX, userID, keyID int64) (int64, error) {
+	result, err := db.ExecContext(ctx, queryRevokeAPIKey, keyID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Authenticate resolves a bearer token, either an API key or a JWT signed
+// with one of the configured keys, to the user it stands for. Bad tokens
+// give ErrInvalidCredentials; any other error comes from the database.
+func Authenticate(ctx context.Context, db DBTX, config AuthConfig, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	if strings.HasPrefix(token, APIKeyPrefix) {
+		var principal Principal
+		err := db.GetContext(ctx, &principal, queryAuthenticateAPIKey, hashAPIKey(token))
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidCredentials
+		}
+		if err != nil {
+			return nil, err
+		}
+		principal.Method = "api_key"
+		return &principal, nil
+	}
+
+	subject, err := verifyJWT(token, config, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	user, err := FindUser(ctx, db, subject)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{UserID: user.ID, Name: user.Name, Email: user.Email, Role: user.Role, Method: "jwt"}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject   string          `json:"sub"`
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt *float64        `json:"exp"`
+	NotBefore *float64        `json:"nbf"`
+}
+
+// verifyJWT checks a compact JWS and returns its subject, the id or email
+// of a user. Only HS256 and RS256 are accepted, each only when its key is
+// configured, and the token must carry an expiry.
+func verifyJWT(token string, config AuthConfig, now time.Time) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidCredentials
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return "", ErrInvalidCredentials
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidCredentials
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	switch header.Alg {
+	case "HS256":
+		if len(config.JWTSecret) == 0 {
+			return "", ErrInvalidCredentials
+		}
+		mac := hmac.New(sha256.New, config.JWTSecret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return "", ErrInvalidCredentials
+		}
+	case "RS256":
+		if config.JWTPublicKey == nil {
+			return "", ErrInvalidCredentials
+		}
+		digest := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(config.JWTPublicKey, crypto.SHA256, digest[:], signature) != nil {
+			return "", ErrInvalidCredentials
+		}
+	default:
+		return "", ErrInvalidCredentials
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return "", ErrInvalidCredentials
+	}
+	if claims.Subject == "" || claims.ExpiresAt == nil {
+		return "", ErrInvalidCredentials
+	}
+	if now.After(unixTime(*claims.ExpiresAt).Add(jwtLeeway)) {
+		return "", ErrInvalidCredentials
+	}
+	if claims.NotBefore != nil && now.Add(jwtLeeway).Before(unixTime(*claims.NotBefore)) {
+		return "", ErrInvalidCredentials
+	}
+	if config.JWTIssuer != "" && claims.Issuer != config.JWTIssuer {
+		return "", ErrInvalidCredentials
+	}
+	if config.JWTAudience != "" && !hasAudience(claims.Audience, config.JWTAudience) {
+		return "", ErrInvalidCredentials
+	}
+	return claims.Subject, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// hasAudience reads aud, which may be a single string or a list of them.
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var one string
+	if json.Unmarshal(raw, &one) == nil {
+		return one == audience
+	}
+	var many []string
+	if json.Unmarshal(raw, &many) == nil {
+		for _, aud := range many {
+			if aud == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseRSAPublicKey reads a PEM encoded RSA public key, either a PKIX
+// "PUBLIC KEY" or a PKCS #1 "RSA PUBLIC KEY" block.
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA public key")
+	}
+	return rsaKey, nil
+}
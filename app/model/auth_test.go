@@ -0,0 +1,192 @@
+package model_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+var testSecret = []byte("test-secret")
+
+// signJWT builds a compact JWS over claims, signed with HS256 when key is a
+// []byte and RS256 when it is an *rsa.PrivateKey.
+func signJWT(t *testing.T, key interface{}, claims map[string]interface{}) string {
+	alg := "HS256"
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		alg = "RS256"
+	}
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	var signature []byte
+	switch key := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		assert.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func expectFindUser(mockDB *model.MockDBTX, id int64) {
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{id}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.User) = model.User{ID: id, Name: "Alice", Email: "alice@example.com"}
+		}).
+		Return(nil).Once()
+}
+
+func TestAuthenticate_HS256(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	config := model.AuthConfig{JWTSecret: testSecret, JWTIssuer: "go-task", JWTAudience: "api"}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	expectFindUser(mockDB, 1)
+	token := signJWT(t, testSecret, map[string]interface{}{"sub": "1", "exp": exp, "iss": "go-task", "aud": []string{"api"}})
+	principal, err := model.Authenticate(context.Background(), mockDB, config, token)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Principal{UserID: 1, Name: "Alice", Email: "alice@example.com", Method: "jwt"}, principal)
+
+	for name, token := range map[string]string{
+		"expired":      signJWT(t, testSecret, map[string]interface{}{"sub": "1", "exp": time.Now().Add(-time.Hour).Unix(), "iss": "go-task", "aud": "api"}),
+		"no expiry":    signJWT(t, testSecret, map[string]interface{}{"sub": "1", "iss": "go-task", "aud": "api"}),
+		"not yet":      signJWT(t, testSecret, map[string]interface{}{"sub": "1", "exp": exp, "nbf": exp, "iss": "go-task", "aud": "api"}),
+		"wrong issuer": signJWT(t, testSecret, map[string]interface{}{"sub": "1", "exp": exp, "iss": "other", "aud": "api"}),
+		"wrong aud":    signJWT(t, testSecret, map[string]interface{}{"sub": "1", "exp": exp, "iss": "go-task", "aud": "web"}),
+		"wrong secret": signJWT(t, []byte("other"), map[string]interface{}{"sub": "1", "exp": exp, "iss": "go-task", "aud": "api"}),
+		"malformed":    "not.a-jwt",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := model.Authenticate(context.Background(), mockDB, config, token)
+			assert.Equal(t, model.ErrInvalidCredentials, err)
+		})
+	}
+}
+
+func TestAuthenticate_RejectsUnconfiguredAlgorithms(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	exp := time.Now().Add(time.Hour).Unix()
+
+	// An HS256 token cannot get in when only RS256 is configured, even when
+	// it is signed with the public key.
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	publicDER := x509.MarshalPKCS1PublicKey(&key.PublicKey)
+	config := model.AuthConfig{JWTPublicKey: &key.PublicKey}
+
+	token := signJWT(t, publicDER, map[string]interface{}{"sub": "1", "exp": exp})
+	_, err = model.Authenticate(context.Background(), mockDB, config, token)
+	assert.Equal(t, model.ErrInvalidCredentials, err)
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1","exp":9999999999}`))
+	_, err = model.Authenticate(context.Background(), mockDB, config, header+"."+payload+".")
+	assert.Equal(t, model.ErrInvalidCredentials, err)
+}
+
+func TestAuthenticate_RS256(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+	publicKey, err := model.ParseRSAPublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	assert.NoError(t, err)
+
+	expectFindUser(mockDB, 1)
+	token := signJWT(t, key, map[string]interface{}{"sub": "1", "exp": time.Now().Add(time.Hour).Unix()})
+	principal, err := model.Authenticate(context.Background(), mockDB, model.AuthConfig{JWTPublicKey: publicKey}, token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), principal.UserID)
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	key := "gtk_local-dev-key-alice"
+	sum := sha256.Sum256([]byte(key))
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{hex.EncodeToString(sum[:])}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "k.revoked_at IS NULL"))
+			*dest.(*model.Principal) = model.Principal{UserID: 1, APIKeyID: 4}
+		}).
+		Return(nil).Once()
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(sql.ErrNoRows).Once()
+
+	principal, err := model.Authenticate(context.Background(), mockDB, model.AuthConfig{}, key)
+	assert.NoError(t, err)
+	assert.Equal(t, &model.Principal{UserID: 1, APIKeyID: 4, Method: "api_key"}, principal)
+
+	_, err = model.Authenticate(context.Background(), mockDB, model.AuthConfig{}, "gtk_revoked")
+	assert.Equal(t, model.ErrInvalidCredentials, err)
+
+	_, err = model.Authenticate(context.Background(), mockDB, model.AuthConfig{}, "")
+	assert.Equal(t, model.ErrMissingCredentials, err)
+}
+
+func TestMintAPIKey(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	var hash string
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, int64(1), args[0])
+			assert.Equal(t, "ci", args[1])
+			hash = args[3].(string)
+		}).
+		Return(&mockResult{lastInsertID: 4, rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4)}).
+		Return(nil)
+
+	key, err := model.MintAPIKey(context.Background(), mockDB, 1, &model.CreateAPIKeyRequest{Name: "ci"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key.Key, model.APIKeyPrefix))
+
+	// What is stored is the hash of the key handed out, never the key.
+	sum := sha256.Sum256([]byte(key.Key))
+	assert.Equal(t, hex.EncodeToString(sum[:]), hash)
+}
+
+func TestAuthConfig_String(t *testing.T) {
+	config := &model.Configuration{Auth: model.AuthConfig{JWTSecret: []byte("hs256-signing-secret"), JWTIssuer: "go-task"}}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		t.Run(format, func(t *testing.T) {
+			printed := fmt.Sprintf(format, config)
+			assert.False(t, strings.Contains(printed, "hs256-signing-secret"))
+			assert.False(t, strings.Contains(printed, fmt.Sprint(config.Auth.JWTSecret)))
+			assert.False(t, strings.Contains(printed, strings.TrimPrefix(fmt.Sprintf("%#v", config.Auth.JWTSecret), "[]byte")))
+			assert.True(t, strings.Contains(printed, "go-task"))
+		})
+	}
+}
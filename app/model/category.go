@@ -1,6 +1,423 @@
 package model
 
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// CategorySeparator joins the names along a category path, as in
+	// "Backend/Auth/OAuth".
+	CategorySeparator = "/"
+
+	// maxCategoryName matches the width of categories.name and
+	// maxCategoryPath that of categories.path.
+	maxCategoryName = 100
+	maxCategoryPath = 760
+	// maxSuggestions caps the "did you mean" list for each unknown name.
+	maxSuggestions = 3
+)
+
+var (
+	ErrInvalidCategoryName = errors.New("category names must be 1 to 100 characters and paths at most 760")
+	ErrCategoryExists      = errors.New("category already exists")
+	ErrCategoryMove        = errors.New("a category can be renamed but not moved, the name must not contain " + CategorySeparator)
+)
+
+// Category is one node of a project's category tree. Path is the full name
+// from the root, e.g. "Backend/Auth", and is what tasks show as their
+// category_name; it is unique within the project.
 type Category struct {
-	ID   uint64 `json:"id" db:"id"`
-	Name string `json:"name" db:"name"`
+	ID       uint64  `json:"id" db:"id"`
+	Name     string  `json:"name" db:"name"`
+	ParentID *uint64 `json:"parent_id" db:"parent_id"`
+	Path     string  `json:"path" db:"path"`
+}
+
+// CategoryWithCount is a category with the number of live tasks filed
+// directly under it; tasks in the trash or in subcategories are not counted.
+type CategoryWithCount struct {
+	Category
+	TaskCount int64 `json:"task_count" db:"task_count"`
+}
+
+// UnknownCategoryError lists category names that matched no category, each
+// with the closest existing names.
+type UnknownCategoryError struct {
+	Names       []string            `json:"unknown_categories"`
+	Suggestions map[string][]string `json:"suggestions"`
+}
+
+func (e *UnknownCategoryError) Error() string {
+	return fmt.Sprintf("unknown category: %s", strings.Join(e.Names, ", "))
+}
+
+// CategoryRequest creates a category from a path, or renames one.
+type CategoryRequest struct {
+	Name string `json:"name"`
+}
+
+// Validate replaces the name with its clean path.
+func (r *CategoryRequest) Validate() error {
+	path, err := CleanCategoryPath(r.Name)
+	if err != nil {
+		return err
+	}
+	r.Name = path
+	return nil
+}
+
+// CleanCategoryPath trims the space around every segment of a category path,
+// so " Backend / Auth " becomes "Backend/Auth", and checks that they fit.
+func CleanCategoryPath(path string) (string, error) {
+	segments := strings.Split(path, CategorySeparator)
+	for i, segment := range segments {
+		segment = strings.TrimSpace(segment)
+		if segment == "" || utf8.RuneCountInString(segment) > maxCategoryName {
+			return "", ErrInvalidCategoryName
+		}
+		segments[i] = segment
+	}
+
+	path = strings.Join(segments, CategorySeparator)
+	if utf8.RuneCountInString(path) > maxCategoryPath {
+		return "", ErrInvalidCategoryName
+	}
+	return path, nil
+}
+
+const (
+	queryGetCategories = `
+		SELECT c.id, c.name, c.parent_id, c.path, COUNT(t.id) AS task_count
+		FROM categories c
+		LEFT JOIN tasks t ON t.category_id = c.id AND t.deleted_at IS NULL
+		WHERE c.project_id = ?
+	`
+
+	queryGroupCategories = `
+		GROUP BY c.id, c.name, c.parent_id, c.path
+	`
+
+	queryCategoryPaths = `SELECT path FROM categories WHERE project_id = ?`
+
+	queryGetCategory = `SELECT id, name, parent_id, path FROM categories WHERE id = ? AND project_id = ?`
+
+	queryCreateCategory = `INSERT INTO categories (project_id, name, parent_id, path) VALUES (?, ?, ?, ?)`
+
+	// On an existing path the no-op update hands back its id through
+	// LastInsertId instead of failing.
+	queryEnsureCategory = `
+	INSERT INTO categories (project_id, name, parent_id, path) VALUES (?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)
+	`
+
+	// queryCategoryID finds the category a task write names, within the
+	// task's project. It takes the :project_id and :category_name named
+	// parameters.
+	queryCategoryID = `(SELECT id FROM categories WHERE project_id = :project_id AND path = :category_name)`
+
+	queryRenameCategory = `UPDATE categories SET name = ?, path = ? WHERE id = ?`
+
+	// Rewrites the path prefix of every category below the renamed one.
+	queryRenameSubcategories = `
+	WITH RECURSIVE subcategories AS (
+		SELECT id
+		FROM categories
+		WHERE parent_id = ?
+
+		UNION ALL
+
+		SELECT c.id
+		FROM categories c
+		INNER JOIN subcategories s ON c.parent_id = s.id
+	)
+	UPDATE categories SET path = CONCAT(?, SUBSTRING(path, ?))
+	WHERE id IN (SELECT id FROM subcategories);
+	`
+
+	// Subcategories go too, through the ON DELETE CASCADE on parent_id, and
+	// tasks in any of them are left without one by the ON DELETE SET NULL on
+	// tasks.category_id.
+	queryDeleteCategory = `DELETE FROM categories WHERE id = ? AND project_id = ?`
+
+	// queryCategoryTree selects the ids of the categories at the given paths
+	// in a project and of everything below them. It is completed with the
+	// placeholders for the paths, which come before the project.
+	queryCategoryTree = `
+	WITH RECURSIVE category_tree AS (
+		SELECT id
+		FROM categories
+		WHERE path IN (%s) AND project_id = ?
+
+		UNION ALL
+
+		SELECT c.id
+		FROM categories c
+		INNER JOIN category_tree ct ON c.parent_id = ct.id
+	)
+	SELECT id FROM category_tree
+	`
+)
+
+// GetCategories lists every category, ordered by path so that each one
+// follows its parent.
+func GetCategories(ctx context.Context, db DBTX, projectID int64) ([]CategoryWithCount, error) {
+	var categories []CategoryWithCount
+	err := db.SelectContext(ctx, &categories, queryGetCategories+queryGroupCategories+" ORDER BY c.path ASC", projectID)
+	return categories, err
+}
+
+func GetCategoryByID(ctx context.Context, db DBTX, projectID int64, categoryID uint64) (*CategoryWithCount, error) {
+	var category CategoryWithCount
+	query := queryGetCategories + " AND c.id = ?" + queryGroupCategories
+	if err := db.GetContext(ctx, &category, query, projectID, categoryID); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CreateCategory creates the category at req.Name, a clean path, along with
+// any of its ancestors that do not exist yet.
+func CreateCategory(ctx context.Context, db DBTX, projectID int64, req *CategoryRequest) (*Category, error) {
+	var category *Category
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		segments := strings.Split(req.Name, CategorySeparator)
+		leaf := segments[len(segments)-1]
+
+		var parentID *uint64
+		if len(segments) > 1 {
+			id, err := ensureCategory(ctx, tx, projectID, strings.Join(segments[:len(segments)-1], CategorySeparator))
+			if err != nil {
+				return err
+			}
+			parentID = &id
+		}
+
+		result, err := tx.ExecContext(ctx, queryCreateCategory, projectID, leaf, parentID, req.Name)
+		if err != nil {
+			if isDuplicateKey(err) {
+				return ErrCategoryExists
+			}
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		category = &Category{ID: uint64(id), Name: leaf, ParentID: parentID, Path: req.Name}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// RenameCategory changes the last segment of a category's path. Its
+// subcategories' paths follow, and so do the tasks in it, since they
+// reference the category by id.
+func RenameCategory(ctx context.Context, db DBTX, projectID int64, categoryID uint64, req *CategoryRequest) (int64, error) {
+	if strings.Contains(req.Name, CategorySeparator) {
+		return 0, ErrCategoryMove
+	}
+
+	var affected int64
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		var current Category
+		if err := tx.GetContext(ctx, &current, queryGetCategory, categoryID, projectID); err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+
+		path := req.Name
+		if i := strings.LastIndex(current.Path, CategorySeparator); i >= 0 {
+			path = current.Path[:i+len(CategorySeparator)] + req.Name
+		}
+
+		res, err := tx.ExecContext(ctx, queryRenameCategory, req.Name, path, categoryID)
+		if err != nil {
+			if isDuplicateKey(err) {
+				return ErrCategoryExists
+			}
+			return err
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return err
+		}
+
+		// SUBSTRING counts characters from 1, so this keeps everything after
+		// the old path, separator included.
+		_, err = tx.ExecContext(ctx, queryRenameSubcategories, categoryID, path, utf8.RuneCountInString(current.Path)+1)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// DeleteCategory removes a category and its subcategories; their tasks
+// become uncategorized.
+func DeleteCategory(ctx context.Context, db DBTX, projectID int64, categoryID uint64) (int64, error) {
+	res, err := db.ExecContext(ctx, queryDeleteCategory, categoryID, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ensureCategory returns the id of the category at a clean path, creating it
+// and its missing ancestors first.
+func ensureCategory(ctx context.Context, db DBTX, projectID int64, path string) (uint64, error) {
+	var (
+		parentID *uint64
+		prefix   string
+	)
+	for _, segment := range strings.Split(path, CategorySeparator) {
+		if prefix != "" {
+			prefix += CategorySeparator
+		}
+		prefix += segment
+
+		result, err := db.ExecContext(ctx, queryEnsureCategory, projectID, segment, parentID, prefix)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		parentID = new(uint64)
+		*parentID = uint64(id)
+	}
+	return *parentID, nil
+}
+
+// ResolveCategories checks that every name is the path of a category of the
+// project, using the database's collation to compare them. Otherwise it returns an
+// *UnknownCategoryError with suggestions for the names that did not match.
+func ResolveCategories(ctx context.Context, db DBTX, projectID int64, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	args := []interface{}{projectID}
+	for _, name := range names {
+		args = append(args, name)
+	}
+
+	var found []string
+	query := queryCategoryPaths + " AND path IN (" + placeholders(len(names)) + ")"
+	if err := db.SelectContext(ctx, &found, query, args...); err != nil {
+		return err
+	}
+
+	unknown := make([]string, 0, len(names))
+	for _, name := range names {
+		if !containsFold(found, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	var existing []string
+	if err := db.SelectContext(ctx, &existing, queryCategoryPaths, projectID); err != nil {
+		return err
+	}
+
+	suggestions := make(map[string][]string, len(unknown))
+	for _, name := range unknown {
+		suggestions[name] = suggestCategories(name, existing)
+	}
+	return &UnknownCategoryError{Names: unknown, Suggestions: suggestions}
+}
+
+// resolveTaskCategory makes sure a task write names a usable category,
+// creating it first when the caller asked for that, and returns its clean
+// path.
+func resolveTaskCategory(ctx context.Context, db DBTX, projectID int64, name string, opts WriteOptions) (string, error) {
+	path, err := CleanCategoryPath(name)
+	if err != nil {
+		return "", err
+	}
+	if opts.CreateCategory {
+		_, err = ensureCategory(ctx, db, projectID, path)
+		return path, err
+	}
+	return path, ResolveCategories(ctx, db, projectID, []string{path})
+}
+
+// containsFold matches name against the stored names the database returned,
+// which differ in case from the request under a case-insensitive collation.
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestCategories returns the existing paths within a few edits of name,
+// closest first. Case is ignored, so "backend" suggests "Backend".
+func suggestCategories(name string, existing []string) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+
+	target := strings.ToLower(name)
+	limit := utf8.RuneCountInString(target)/3 + 1
+
+	var candidates []candidate
+	for _, n := range existing {
+		if d := editDistance(target, strings.ToLower(n)); d <= limit {
+			candidates = append(candidates, candidate{n, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	out := []string{}
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		out = append(out, candidates[i].name)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
 }
@@ -0,0 +1,231 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+// expectCategories stubs the category lookup of a task write in project 1,
+// finding every name it is asked for.
+func expectCategories(mockDB *model.MockDBTX, names ...string) {
+	args := []interface{}{int64(1)}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, args).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]string) = names
+		}).
+		Return(nil).
+		Once()
+}
+
+func TestCategoryRequest_Validate(t *testing.T) {
+	req := model.CategoryRequest{Name: "  Backend "}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "Backend", req.Name)
+
+	req = model.CategoryRequest{Name: "   "}
+	assert.Equal(t, model.ErrInvalidCategoryName, req.Validate())
+
+	req = model.CategoryRequest{Name: strings.Repeat("x", 101)}
+	assert.Equal(t, model.ErrInvalidCategoryName, req.Validate())
+}
+
+func TestGetCategories_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			// Only the project's categories, and trashed tasks do not count
+			assert.True(t, strings.Contains(query, "c.project_id = ?"))
+			assert.True(t, strings.Contains(query, "t.deleted_at IS NULL"))
+			*dest.(*[]model.CategoryWithCount) = []model.CategoryWithCount{
+				{Category: model.Category{ID: 1, Name: "Backend"}, TaskCount: 3},
+			}
+		}).
+		Return(nil)
+
+	categories, err := model.GetCategories(context.Background(), mockDB, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(categories))
+	assert.Equal(t, int64(3), categories[0].TaskCount)
+}
+
+func TestCleanCategoryPath(t *testing.T) {
+	path, err := model.CleanCategoryPath(" Backend / Auth ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Backend/Auth", path)
+
+	_, err = model.CleanCategoryPath("Backend//Auth")
+	assert.Equal(t, model.ErrInvalidCategoryName, err)
+}
+
+func TestCreateCategory_Duplicate(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "Backend", (*uint64)(nil), "Backend"}).
+		Return(nil, &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+	_, err := model.CreateCategory(context.Background(), mockDB, 1, &model.CategoryRequest{Name: "Backend"})
+
+	assert.Equal(t, model.ErrCategoryExists, err)
+}
+
+func TestCreateCategory_Path(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Missing ancestors are created on the way down
+	backend := uint64(1)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "Backend", (*uint64)(nil), "Backend"}).
+		Return(&mockResult{lastInsertID: 1, rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "Auth", &backend, "Backend/Auth"}).
+		Return(&mockResult{lastInsertID: 2, rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "OAuth", func() *uint64 { id := uint64(2); return &id }(), "Backend/Auth/OAuth"}).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.False(t, strings.Contains(query, "ON DUPLICATE KEY"))
+		}).
+		Return(&mockResult{lastInsertID: 3, rowsAffected: 1}, nil)
+
+	category, err := model.CreateCategory(context.Background(), mockDB, 1, &model.CategoryRequest{Name: "Backend/Auth/OAuth"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), category.ID)
+	assert.Equal(t, "OAuth", category.Name)
+	assert.Equal(t, uint64(2), *category.ParentID)
+}
+
+func TestRenameCategory_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{uint64(2), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Category) = model.Category{ID: 2, Name: "Auth", Path: "Backend/Auth"}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{"Identity", "Backend/Identity", uint64(2)}).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	// "Backend/Auth/OAuth" keeps everything from the 13th character on
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{uint64(2), "Backend/Identity", 13}).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	renamed, err := model.RenameCategory(context.Background(), mockDB, 1, 2, &model.CategoryRequest{Name: "Identity"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), renamed)
+}
+
+func TestRenameCategory_Move(t *testing.T) {
+	_, err := model.RenameCategory(context.Background(), model.NewMockDBTX(t), 1, 2, &model.CategoryRequest{Name: "Frontend/Auth"})
+
+	assert.Equal(t, model.ErrCategoryMove, err)
+}
+
+func TestCreateTask_CreateCategory(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	req := &model.CreateTaskRequest{
+		Title:        "New Task",
+		CategoryName: nulltype.NullStringOf("Infra / Cloud"),
+	}
+
+	// The category is created before the task refers to it by its clean path
+	infra := uint64(4)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "Infra", (*uint64)(nil), "Infra"}).
+		Return(&mockResult{lastInsertID: 4, rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "Cloud", &infra, "Infra/Cloud"}).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "ON DUPLICATE KEY UPDATE"))
+		}).
+		Return(&mockResult{lastInsertID: 5, rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, arg interface{}) {
+			assert.Equal(t, nulltype.NullStringOf("Infra/Cloud"), arg.(map[string]interface{})["category_name"])
+		}).
+		Return(&mockResult{lastInsertID: 1, rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 1, Title: "New Task", CategoryID: nulltype.NullInt64Of(5)}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{lastInsertID: 1}, nil)
+
+	task, err := model.CreateTask(context.Background(), mockDB, 1, req, model.WriteOptions{CreateCategory: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), task.CategoryID.Int64Value())
+}
+
+func TestResolveCategories_Unknown(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// "backend" only differs in case, which the database may or may not ignore
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), "Bakend", "backend", "Zzz"}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]string) = []string{"Backend"}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]string) = []string{"Backend", "Bug", "Feature", "Frontend"}
+		}).
+		Return(nil)
+
+	err := model.ResolveCategories(context.Background(), mockDB, 1, []string{"Bakend", "backend", "Zzz"})
+
+	unknown, ok := err.(*model.UnknownCategoryError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Bakend", "Zzz"}, unknown.Names)
+	assert.Equal(t, []string{"Backend"}, unknown.Suggestions["Bakend"])
+	assert.Equal(t, []string{}, unknown.Suggestions["Zzz"])
+}
+
+func TestCreateTask_UnknownCategory(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), "Frontand"}).
+		Return(nil)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]string) = []string{"Backend", "Frontend"}
+		}).
+		Return(nil)
+
+	// Nothing is inserted
+	_, err := model.CreateTask(context.Background(), mockDB, 1, &model.CreateTaskRequest{
+		Title:        "New Task",
+		CategoryName: nulltype.NullStringOf("Frontand"),
+	}, model.WriteOptions{})
+
+	unknown, ok := err.(*model.UnknownCategoryError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"Frontend"}, unknown.Suggestions["Frontand"])
+}
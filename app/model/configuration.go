@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"crypto/rsa"
+	"fmt"
+	"time"
+)
 
 type ApplicationConfig struct {
 	LogLevel string
@@ -12,6 +16,9 @@ type DatabaseConfig struct {
 	DBHost string
 	DBName string
 	DBPort string
+	// QueryTimeout bounds the database work of a single API request. 0 means
+	// no limit.
+	QueryTimeout time.Duration
 }
 
 type ServerConfig struct {
@@ -20,8 +27,88 @@ type ServerConfig struct {
 	GracefulTimeout time.Duration
 }
 
+type TaskConfig struct {
+	// MaxDepth caps how many levels a task hierarchy may have. 0 means unlimited.
+	MaxDepth int
+	// TrashRetention is how long deleted tasks stay restorable before a purge
+	// removes them for good.
+	TrashRetention time.Duration
+}
+
+// ReminderConfig drives the background scan for tasks that are due soon or
+// overdue.
+type ReminderConfig struct {
+	// Interval is how often tasks are scanned. 0 turns reminders off.
+	Interval time.Duration
+	// LeadTimes are how long before the end of the due date a reminder goes
+	// out. Overdue tasks are always reminded once.
+	LeadTimes []time.Duration
+	// Notifier is "log" or "smtp".
+	Notifier string
+	// SMTPAddr and SMTPFrom are the relay and the sender of reminder mails.
+	SMTPAddr string
+	SMTPFrom string
+}
+
+// OutboxConfig drives the publishing of the task events in the outbox.
+type OutboxConfig struct {
+	// Interval is how often the outbox is drained. 0 turns publishing off;
+	// events still pile up.
+	Interval time.Duration
+	// Sinks are where events go: "webhook", "file" and/or "stdout".
+	Sinks []string
+	// File is where the file sink appends events, one JSON object a line.
+	File string
+	// Retention is how long published events are kept. 0 keeps them.
+	Retention time.Duration
+}
+
+// WebhookConfig drives the background delivery of webhook events.
+type WebhookConfig struct {
+	// Interval is how often pending deliveries are looked for. 0 turns
+	// delivery off; events still queue up.
+	Interval time.Duration
+	// MaxAttempts is how many times a delivery is tried before it goes to
+	// the dead letter table.
+	MaxAttempts int
+	// Timeout bounds a single attempt.
+	Timeout time.Duration
+}
+
+// AuthConfig holds the keys JWT bearer tokens are verified with. A token
+// signed with an algorithm whose key is not configured is rejected.
+type AuthConfig struct {
+	// JWTSecret verifies HS256 tokens.
+	JWTSecret []byte
+	// JWTPublicKey verifies RS256 tokens.
+	JWTPublicKey *rsa.PublicKey
+	// JWTIssuer and JWTAudience, when set, must match the iss and aud claims.
+	JWTIssuer   string
+	JWTAudience string
+}
+
+// String describes the configuration without the JWT secret, which would
+// let anyone who reads it sign tokens. The public key is only named.
+func (a AuthConfig) String() string {
+	secret := ""
+	if len(a.JWTSecret) > 0 {
+		secret = "[REDACTED]"
+	}
+	return fmt.Sprintf("{JWTSecret:%s JWTPublicKey:%t JWTIssuer:%s JWTAudience:%s}", secret, a.JWTPublicKey != nil, a.JWTIssuer, a.JWTAudience)
+}
+
+// GoString is String, so that %#v does not print the secret either.
+func (a AuthConfig) GoString() string {
+	return "model.AuthConfig" + a.String()
+}
+
 type Configuration struct {
 	Application ApplicationConfig
 	Database    DatabaseConfig
 	Server      ServerConfig
+	Task        TaskConfig
+	Auth        AuthConfig
+	Reminder    ReminderConfig
+	Webhook     WebhookConfig
+	Outbox      OutboxConfig
 }
@@ -1,20 +1,26 @@
 package model
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 )
 
-// DBTX is an abstraction over sqlx.DB and sqlx.Tx.
+// DBTX is an abstraction over sqlx.DB and sqlx.Tx. Use WithTx to run several
+// statements as one transaction.
+//
+// Every method takes a context so that a cancelled request or an expired
+// query timeout stops the statement on the server too.
 type DBTX interface {
-	Get(dest interface{}, query string, args ...interface{}) error
-	Select(dest interface{}, query string, args ...interface{}) error
-	Exec(query string, args ...interface{}) (sql.Result, error)
-	NamedExec(query string, arg interface{}) (sql.Result, error)
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error)
 }
 
 func InitDatabases(config DatabaseConfig) (DBTX, error) {
@@ -46,3 +52,89 @@ func InitDatabases(config DatabaseConfig) (DBTX, error) {
 
 	return db, nil
 }
+
+// txBeginner is implemented by *sqlx.DB. A DBTX that is already a transaction
+// does not implement it, so nested calls simply join the outer transaction.
+type txBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+const (
+	maxTxAttempts = 3
+	txRetryDelay  = 20 * time.Millisecond
+)
+
+// retryableErrors are the MySQL and TiDB error numbers after which a
+// transaction can simply be run again. A deadlock rolls back the whole
+// transaction on the server, but a lock wait timeout by default only rolls
+// back the statement that waited; WithTx rolls back the rest before it
+// starts over either way.
+var retryableErrors = map[uint16]bool{
+	1205: true, // ER_LOCK_WAIT_TIMEOUT, statement only
+	1213: true, // ER_LOCK_DEADLOCK
+	8002: true, // TiDB: SELECT FOR UPDATE write conflict
+	8022: true, // TiDB: transaction commit failed, retry
+	9007: true, // TiDB: write conflict
+}
+
+// isDuplicateKey reports whether err is a unique key violation.
+func isDuplicateKey(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == 1062 // ER_DUP_ENTRY
+}
+
+// IsRetryable reports whether err is a deadlock or serialization failure
+// that a fresh attempt of the same transaction may not hit.
+func IsRetryable(err error) bool {
+	var myErr *mysql.MySQLError
+	return errors.As(err, &myErr) && retryableErrors[myErr.Number]
+}
+
+// WithTx runs fn as a single unit of work. fn must do all of its work through
+// the DBTX it is given: the changes are committed when it returns nil and
+// rolled back when it returns an error or panics. Deadlocks, lock wait
+// timeouts and write conflicts roll back and run fn again, up to
+// maxTxAttempts times, so fn must not have side effects outside the
+// database.
+//
+// When db is already a transaction, fn joins it and any retry is left to
+// whoever started it.
+func WithTx(ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = runTx(ctx, beginner, fn)
+		if err == nil || !IsRetryable(err) || attempt == maxTxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt) * txRetryDelay):
+		}
+	}
+}
+
+func runTx(ctx context.Context, beginner txBeginner, fn func(tx DBTX) error) error {
+	tx, err := beginner.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
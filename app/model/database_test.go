@@ -0,0 +1,143 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/zeebo/assert"
+)
+
+// txDriver is a database/sql driver that only knows how to begin, commit
+// and roll back, which is all WithTx itself does.
+type txDriver struct {
+	begins, commits, rollbacks int
+}
+
+func (d *txDriver) Open(string) (driver.Conn, error) { return txConn{d}, nil }
+
+type txConn struct{ d *txDriver }
+
+func (c txConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c txConn) Close() error                        { return nil }
+func (c txConn) Begin() (driver.Tx, error) {
+	c.d.begins++
+	return c, nil
+}
+func (c txConn) Commit() error {
+	c.d.commits++
+	return nil
+}
+func (c txConn) Rollback() error {
+	c.d.rollbacks++
+	return nil
+}
+
+func newTxDB(t *testing.T) (*sqlx.DB, *txDriver) {
+	d := &txDriver{}
+	name := fmt.Sprintf("txdriver-%s", t.Name())
+	sql.Register(name, d)
+	db, err := sqlx.Open(name, "")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, d
+}
+
+func TestWithTx_Commit(t *testing.T) {
+	db, d := newTxDB(t)
+
+	err := model.WithTx(context.Background(), db, func(tx model.DBTX) error {
+		_, ok := tx.(*sqlx.Tx)
+		assert.True(t, ok)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, d.commits)
+	assert.Equal(t, 0, d.rollbacks)
+}
+
+func TestWithTx_RetriesDeadlock(t *testing.T) {
+	db, d := newTxDB(t)
+
+	calls := 0
+	err := model.WithTx(context.Background(), db, func(tx model.DBTX) error {
+		calls++
+		if calls < 3 {
+			return &mysql.MySQLError{Number: 1213, Message: "Deadlock found"}
+		}
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, d.begins)
+	assert.Equal(t, 2, d.rollbacks)
+	assert.Equal(t, 1, d.commits)
+}
+
+func TestWithTx_GivesUp(t *testing.T) {
+	db, d := newTxDB(t)
+
+	conflict := &mysql.MySQLError{Number: 9007, Message: "Write conflict"}
+	err := model.WithTx(context.Background(), db, func(tx model.DBTX) error {
+		return conflict
+	})
+
+	assert.Equal(t, conflict, err)
+	assert.Equal(t, 3, d.begins)
+	assert.Equal(t, 0, d.commits)
+}
+
+func TestWithTx_NoRetryOnOtherErrors(t *testing.T) {
+	db, d := newTxDB(t)
+
+	err := model.WithTx(context.Background(), db, func(tx model.DBTX) error {
+		return sql.ErrNoRows
+	})
+
+	assert.Equal(t, sql.ErrNoRows, err)
+	assert.Equal(t, 1, d.begins)
+	assert.Equal(t, 1, d.rollbacks)
+}
+
+func TestWithTx_RollbackOnPanic(t *testing.T) {
+	db, d := newTxDB(t)
+
+	defer func() {
+		assert.Equal(t, "boom", recover())
+		assert.Equal(t, 1, d.rollbacks)
+		assert.Equal(t, 0, d.commits)
+	}()
+
+	_ = model.WithTx(context.Background(), db, func(tx model.DBTX) error {
+		panic("boom")
+	})
+}
+
+func TestWithTx_JoinsTransaction(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// A DBTX that cannot begin is used as is, and is not retried
+	calls := 0
+	err := model.WithTx(context.Background(), mockDB, func(tx model.DBTX) error {
+		calls++
+		assert.Equal(t, model.DBTX(mockDB), tx)
+		return &mysql.MySQLError{Number: 1213}
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, model.IsRetryable(&mysql.MySQLError{Number: 1213}))
+	assert.True(t, model.IsRetryable(fmt.Errorf("update: %w", &mysql.MySQLError{Number: 1205})))
+	assert.False(t, model.IsRetryable(&mysql.MySQLError{Number: 1062}))
+	assert.False(t, model.IsRetryable(sql.ErrNoRows))
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// MaxStreamEvents caps how many events a single read of the stream returns.
+const MaxStreamEvents = 100
+
+// ErrEventNotKept is returned for a stream resuming after an event the
+// outbox no longer has, see OUTBOX_RETENTION.
+var ErrEventNotKept = errors.New("events after Last-Event-ID are no longer kept")
+
+// EventFilter narrows the task events streamed to a client to those of one
+// project. Category and RootID are matched against where the task is now,
+// so a task moved out of them is no longer followed.
+type EventFilter struct {
+	ProjectID int64
+	// Category is a category path of ProjectID; its subcategories are
+	// included.
+	Category string
+	// RootID follows a task of ProjectID and every task below it.
+	RootID null.NullInt64
+}
+
+const (
+	queryOutboxHead = `SELECT COALESCE(MAX(id), 0) FROM outbox`
+
+	queryOutboxCreatedAt = `SELECT created_at FROM outbox WHERE id = ?`
+
+	queryOutboxWrittenBefore = `SELECT id FROM outbox WHERE id < ? AND created_at <= ? ORDER BY id DESC LIMIT 1`
+
+	queryStreamEvents = `
+		SELECT o.id, o.project_id, o.task_id, o.event, o.payload, o.created_at
+		FROM outbox o
+		WHERE o.id > ? AND o.project_id = ?
+	`
+
+	// querySubtreeIDs lists a task of the project and its descendants, trash
+	// included, so that deletions are matched too.
+	querySubtreeIDs = `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM tasks WHERE id = ? AND project_id = ?
+
+		UNION ALL
+
+		SELECT t.id
+		FROM tasks t
+		INNER JOIN subtree s ON t.parent_task_id = s.id
+	)
+	SELECT id FROM subtree
+	`
+)
+
+// GetOutboxHead returns the highest ID handed out to an outbox event so
+// far, or 0 when the outbox is empty.
+func GetOutboxHead(ctx context.Context, db DBTX) (int64, error) {
+	var head int64
+	err := db.GetContext(ctx, &head, queryOutboxHead)
+	return head, err
+}
+
+// GetResumePoint returns the ID a stream resuming after the event lastID
+// reads from: the last event written grace before it, or 0. Events with
+// lower IDs than lastID may have committed after it, within grace. It
+// returns ErrEventNotKept when the outbox no longer has lastID, so that
+// later events may be gone too.
+func GetResumePoint(ctx context.Context, db DBTX, lastID int64, grace time.Duration) (int64, error) {
+	var createdAt time.Time
+	if err := db.GetContext(ctx, &createdAt, queryOutboxCreatedAt, lastID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrEventNotKept
+		}
+		return 0, err
+	}
+
+	var from int64
+	if err := db.GetContext(ctx, &from, queryOutboxWrittenBefore, lastID, createdAt.Add(-grace)); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return from, nil
+}
+
+// GetStreamEvents returns up to limit events of the outbox after the given
+// ID that match the filter, oldest first.
+func GetStreamEvents(ctx context.Context, db DBTX, filter *EventFilter, after int64, limit int) ([]OutboxEvent, error) {
+	query := queryStreamEvents
+	args := []interface{}{after, filter.ProjectID}
+
+	if filter.Category != "" {
+		query += " AND o.task_id IN (SELECT t.id FROM tasks t WHERE t.category_id IN (" + fmt.Sprintf(queryCategoryTree, "?") + "))"
+		args = append(args, filter.Category, filter.ProjectID)
+	}
+	if filter.RootID.Valid() {
+		query += " AND o.task_id IN (" + querySubtreeIDs + ")"
+		args = append(args, filter.RootID.Int64Value(), filter.ProjectID)
+	}
+	query += " ORDER BY o.id LIMIT ?"
+	args = append(args, limit)
+
+	events := []OutboxEvent{}
+	err := db.SelectContext(ctx, &events, query, args...)
+	return events, err
+}
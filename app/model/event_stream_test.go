@@ -0,0 +1,97 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestGetStreamEvents(t *testing.T) {
+	t.Run("project", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5), int64(1), 100}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				assert.True(t, strings.Contains(query, "o.project_id = ?"))
+			}).
+			Return(nil)
+
+		_, err := model.GetStreamEvents(context.Background(), mockDB, &model.EventFilter{ProjectID: 1}, 5, 100)
+		assert.NoError(t, err)
+	})
+
+	t.Run("every filter", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5), int64(1), "Backend", int64(1), int64(8), int64(1), 100}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				assert.True(t, strings.Contains(query, "category_tree"))
+				// The followed task has to be one of the project's.
+				assert.True(t, strings.Contains(query, "WHERE id = ? AND project_id = ?"))
+			}).
+			Return(nil)
+
+		filter := &model.EventFilter{
+			ProjectID: 1,
+			Category:  "Backend",
+			RootID:    nulltype.NullInt64Of(8),
+		}
+		_, err := model.GetStreamEvents(context.Background(), mockDB, filter, 5, 100)
+		assert.NoError(t, err)
+	})
+}
+
+func TestGetResumePoint(t *testing.T) {
+	written := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("kept", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*time.Time) = written
+			}).
+			Return(nil)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5), written.Add(-10 * time.Second)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*int64) = 3
+			}).
+			Return(nil)
+
+		from, err := model.GetResumePoint(context.Background(), mockDB, 5, 10*time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), from)
+	})
+
+	t.Run("nothing before", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5)}).
+			Return(nil)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(sql.ErrNoRows)
+
+		from, err := model.GetResumePoint(context.Background(), mockDB, 5, 10*time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), from)
+	})
+
+	t.Run("purged", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5)}).
+			Return(sql.ErrNoRows)
+
+		_, err := model.GetResumePoint(context.Background(), mockDB, 5, 10*time.Second)
+		assert.Equal(t, model.ErrEventNotKept, err)
+	})
+}
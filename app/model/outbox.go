@@ -0,0 +1,180 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// EventType is what happened to a task, as published from the outbox.
+type EventType string
+
+const (
+	EventTaskCreated EventType = "task.created"
+	EventTaskUpdated EventType = "task.updated"
+	// EventTaskCompleted replaces task.updated for the update that moves a
+	// task to done.
+	EventTaskCompleted EventType = "task.completed"
+	EventTaskDeleted   EventType = "task.deleted"
+	// EventTaskMoved is a change of parent or position.
+	EventTaskMoved EventType = "task.moved"
+	// EventTaskRestored is sent for a task taken out of the trash and for
+	// each subtask that comes back with it.
+	EventTaskRestored EventType = "task.restored"
+)
+
+var eventTypes = []EventType{EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventTaskDeleted, EventTaskMoved, EventTaskRestored}
+
+func (e EventType) IsValid() bool {
+	return slices.Contains(eventTypes, e)
+}
+
+// EventPayload is what is published about a task event. Task is the task
+// after the change; a deleted task only has its id.
+type EventPayload struct {
+	Event      EventType `json:"event"`
+	ProjectID  int64     `json:"project_id"`
+	TaskID     int64     `json:"task_id"`
+	Task       *Task     `json:"task,omitempty"`
+	Changes    Changes   `json:"changes"`
+	Actor      string    `json:"actor,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// OutboxEvent is a task event waiting in the outbox, or on its way out.
+type OutboxEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	ProjectID int64     `json:"project_id" db:"project_id"`
+	TaskID    int64     `json:"task_id" db:"task_id"`
+	Event     EventType `json:"event" db:"event"`
+	Payload   RawJSON   `json:"payload" db:"payload"`
+	Attempts  int       `json:"-" db:"attempts"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// maxErrorMessage matches the width of the last_error columns.
+const maxErrorMessage = 255
+
+const (
+	queryInsertOutbox = `INSERT INTO outbox (project_id, task_id, event, payload) VALUES (?, ?, ?, ?)`
+
+	// queryClaimOutbox locks the events that are due, oldest first. An event
+	// is left for later while an earlier one of its task is still claimed or
+	// waiting for a retry, so that the events of a task go out in order.
+	queryClaimOutbox = `
+		SELECT o.id, o.project_id, o.task_id, o.event, o.payload, o.attempts, o.created_at
+		FROM outbox o
+		WHERE o.published_at IS NULL AND o.next_attempt_at <= ?
+		AND NOT EXISTS (
+			SELECT 1 FROM outbox e
+			WHERE e.task_id = o.task_id AND e.id < o.id
+			AND e.published_at IS NULL AND e.next_attempt_at > ?
+		)
+		ORDER BY o.id
+		LIMIT ?
+		FOR UPDATE
+	`
+
+	queryPublishedOutbox = `UPDATE outbox SET published_at = ?, last_error = NULL WHERE id = ?`
+
+	queryFailedOutbox = `UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`
+
+	queryPurgeOutbox = `DELETE FROM outbox WHERE published_at < ? LIMIT ?`
+)
+
+// recordOutboxEvent puts a task event in the outbox. It is meant to run on
+// the same transaction as the change, so that the event is published if and
+// only if the change is committed.
+func recordOutboxEvent(ctx context.Context, db DBTX, projectID int64, event EventType, taskID int64, task *Task, changes Changes, actor string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(EventPayload{
+		Event:      event,
+		ProjectID:  projectID,
+		TaskID:     taskID,
+		Task:       task,
+		Changes:    changes,
+		Actor:      actor,
+		OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, queryInsertOutbox, projectID, taskID, event, string(payload))
+	return err
+}
+
+// updateEvent is task.completed for an update that moved the task to done.
+func updateEvent(changes Changes) EventType {
+	if change, ok := changes["status"]; ok && change.To == string(StatusDone) {
+		return EventTaskCompleted
+	}
+	return EventTaskUpdated
+}
+
+// ClaimOutbox reserves up to limit events that are due, oldest first, until
+// the given time. Events that are neither published nor released by then are
+// claimed again, so an event is published at least once.
+func ClaimOutbox(ctx context.Context, db DBTX, now, until time.Time, limit int) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		events = nil
+		if err := tx.SelectContext(ctx, &events, queryClaimOutbox, now, now, limit); err != nil {
+			return err
+		}
+		return RescheduleOutbox(ctx, tx, outboxIDs(events), until)
+	})
+	return events, err
+}
+
+// RescheduleOutbox makes events due again at the given time. Releasing a
+// claim is rescheduling to now.
+func RescheduleOutbox(ctx context.Context, db DBTX, ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := []interface{}{at}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET next_attempt_at = ? WHERE id IN ("+placeholders(len(ids))+")", args...)
+	return err
+}
+
+// MarkOutboxPublished records that every sink has the event.
+func MarkOutboxPublished(ctx context.Context, db DBTX, id int64, at time.Time) error {
+	_, err := db.ExecContext(ctx, queryPublishedOutbox, at, id)
+	return err
+}
+
+// MarkOutboxFailed records a failed attempt to publish an event, which is
+// tried again at retryAt. The later events of its task wait for it.
+func MarkOutboxFailed(ctx context.Context, db DBTX, id int64, message string, retryAt time.Time) error {
+	if utf8.RuneCountInString(message) > maxErrorMessage {
+		message = string([]rune(message)[:maxErrorMessage])
+	}
+	_, err := db.ExecContext(ctx, queryFailedOutbox, message, retryAt, id)
+	return err
+}
+
+// PurgeOutbox deletes up to limit events published before the given time.
+func PurgeOutbox(ctx context.Context, db DBTX, before time.Time, limit int) (int64, error) {
+	res, err := db.ExecContext(ctx, queryPurgeOutbox, before, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func outboxIDs(events []OutboxEvent) []int64 {
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+	return ids
+}
@@ -0,0 +1,61 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestClaimOutbox(t *testing.T) {
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	until := now.Add(time.Minute)
+
+	t.Run("claimed", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{now, now, 100}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				assert.True(t, strings.Contains(query, "FOR UPDATE"))
+				*dest.(*[]model.OutboxEvent) = []model.OutboxEvent{{ID: 1, TaskID: 4}, {ID: 2, TaskID: 5}}
+			}).
+			Return(nil).Once()
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, "UPDATE outbox SET next_attempt_at = ? WHERE id IN (?, ?)", []interface{}{until, int64(1), int64(2)}).
+			Return(&mockResult{rowsAffected: 2}, nil).Once()
+
+		events, err := model.ClaimOutbox(context.Background(), mockDB, now, until, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(events))
+	})
+
+	t.Run("nothing due", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(nil).Once()
+
+		events, err := model.ClaimOutbox(context.Background(), mockDB, now, until, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(events))
+	})
+}
+
+func TestMarkOutboxFailed(t *testing.T) {
+	retryAt := time.Date(2026, 3, 2, 15, 0, 5, 0, time.UTC)
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, 255, len(args[0].(string)))
+			assert.Equal(t, []interface{}{retryAt, int64(3)}, args[1:])
+		}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+
+	err := model.MarkOutboxFailed(context.Background(), mockDB, 3, strings.Repeat("x", 300), retryAt)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,308 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// Role is what a user may do: viewers read, members also change tasks and
+// admins may do anything. A user has one role everywhere and may be granted
+// a higher one on a category, which then holds for its subcategories too.
+type Role string
+
+const (
+	RoleViewer Role = "viewer"
+	RoleMember Role = "member"
+	RoleAdmin  Role = "admin"
+)
+
+var ErrInvalidRole = errors.New("role must be viewer, member or admin")
+
+// roleRanks orders the roles; a role includes everything ranked below it.
+var roleRanks = map[Role]int{
+	RoleViewer: 1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+}
+
+func (r Role) IsValid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether r allows everything other allows.
+func (r Role) Includes(other Role) bool {
+	return roleRanks[r] >= roleRanks[other]
+}
+
+func (r *Role) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*r = Role(v)
+	case string:
+		*r = Role(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Role", value)
+	}
+	return nil
+}
+
+func (r Role) Value() (driver.Value, error) {
+	return string(r), nil
+}
+
+// TaskAccess is a task operation the policy rules on.
+type TaskAccess string
+
+const (
+	// AccessEditTask covers updating, moving and restoring a task.
+	AccessEditTask TaskAccess = "edit"
+	// AccessDeleteTask sends a task to the trash with all its subtasks.
+	AccessDeleteTask TaskAccess = "delete"
+)
+
+// ForbiddenError is a refusal by the policy; Reason says what is missing.
+type ForbiddenError struct {
+	Reason string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Reason
+}
+
+// CategoryPermission grants a user a role on a category and everything
+// below it.
+type CategoryPermission struct {
+	UserID       int64  `json:"user_id" db:"user_id"`
+	CategoryID   uint64 `json:"category_id" db:"category_id"`
+	CategoryPath string `json:"category_path" db:"category_path"`
+	Role         Role   `json:"role" db:"role"`
+}
+
+const (
+	queryTaskAccess = `
+		SELECT c.path AS category_path, EXISTS (
+			SELECT 1 FROM tasks s WHERE s.parent_task_id = t.id AND s.deleted_at IS NULL
+		) AS has_subtasks
+		FROM tasks t
+		LEFT JOIN categories c ON c.id = t.category_id
+		WHERE t.id = ? AND t.project_id = ?
+	`
+
+	// querySubtreeCategories lists the category paths of a task and its live
+	// descendants, "" standing for no category.
+	querySubtreeCategories = `
+	WITH RECURSIVE subtree AS (
+		SELECT id, category_id FROM tasks WHERE id = ? AND project_id = ?
+
+		UNION ALL
+
+		SELECT t.id, t.category_id
+		FROM tasks t
+		INNER JOIN subtree s ON t.parent_task_id = s.id
+		WHERE t.deleted_at IS NULL
+	)
+	SELECT DISTINCT COALESCE(c.path, '') AS path
+	FROM subtree s
+	LEFT JOIN categories c ON c.id = s.category_id
+	ORDER BY path
+	`
+
+	// queryGrantedRoles is completed with placeholders for the category
+	// path and each of its ancestors.
+	queryGrantedRoles = `
+		SELECT p.role
+		FROM category_permissions p
+		INNER JOIN categories c ON c.id = p.category_id
+		WHERE p.user_id = ? AND c.project_id = ? AND c.path IN (%s)
+	`
+
+	queryGetCategoryPermissions = `
+		SELECT p.user_id, p.category_id, c.path AS category_path, p.role
+		FROM category_permissions p
+		INNER JOIN categories c ON c.id = p.category_id
+		WHERE p.category_id = ? AND c.project_id = ?
+		ORDER BY p.user_id
+	`
+
+	querySetCategoryPermission = `
+	INSERT INTO category_permissions (user_id, category_id, role) VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE role = VALUES(role)
+	`
+
+	queryDeleteCategoryPermission = `
+	DELETE p FROM category_permissions p
+	INNER JOIN categories c ON c.id = p.category_id
+	WHERE p.category_id = ? AND p.user_id = ? AND c.project_id = ?
+	`
+)
+
+// RequireRole refuses a principal whose own role is below role. Category
+// grants do not count here.
+func RequireRole(principal *Principal, role Role) error {
+	if principal.Role.Includes(role) {
+		return nil
+	}
+	return &ForbiddenError{Reason: fmt.Sprintf("this requires the %s role, you are %s", role, principal.Role)}
+}
+
+// AuthorizeTask rules on an operation on an existing task of the project,
+// trashed or not, given the role the principal holds on the task's
+// category. Changing a task takes a member; deleting one that still has
+// subtasks takes an admin, on the category of every task in the subtree. A
+// task that does not exist is judged as an uncategorized one, which leaves
+// the not found answer to the caller.
+func AuthorizeTask(ctx context.Context, db DBTX, principal *Principal, access TaskAccess, projectID, taskID int64) error {
+	var task struct {
+		CategoryPath *string `db:"category_path"`
+		HasSubtasks  bool    `db:"has_subtasks"`
+	}
+	if err := db.GetContext(ctx, &task, queryTaskAccess, taskID, projectID); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	path := ""
+	if task.CategoryPath != nil {
+		path = *task.CategoryPath
+	}
+	role, err := categoryRole(ctx, db, principal, projectID, path)
+	if err != nil {
+		return err
+	}
+	if err := requireMember(role, path); err != nil {
+		return err
+	}
+
+	if access != AccessDeleteTask || !task.HasSubtasks {
+		return nil
+	}
+	if !role.Includes(RoleAdmin) {
+		return &ForbiddenError{Reason: "only admins can delete a task that has subtasks, delete the subtasks first"}
+	}
+	if principal.Role.Includes(RoleAdmin) {
+		return nil
+	}
+
+	// The subtasks go to the trash too, so an admin grant on the task's
+	// category only covers them where it reaches.
+	var paths []string
+	if err := db.SelectContext(ctx, &paths, querySubtreeCategories, taskID, projectID); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if p == path {
+			continue
+		}
+		role, err := categoryRole(ctx, db, principal, projectID, p)
+		if err != nil {
+			return err
+		}
+		if !role.Includes(RoleAdmin) {
+			return &ForbiddenError{Reason: fmt.Sprintf("the subtasks include %s, which only admins can delete", categoryTasks(p))}
+		}
+	}
+	return nil
+}
+
+// AuthorizeCategory checks that the principal may create or change tasks
+// filed under the category path of the project, "" meaning no category.
+func AuthorizeCategory(ctx context.Context, db DBTX, principal *Principal, projectID int64, path string) error {
+	role, err := categoryRole(ctx, db, principal, projectID, path)
+	if err != nil {
+		return err
+	}
+	return requireMember(role, path)
+}
+
+// requireMember refuses a role held on the category path that cannot
+// change its tasks.
+func requireMember(role Role, path string) error {
+	if role.Includes(RoleMember) {
+		return nil
+	}
+
+	return &ForbiddenError{Reason: fmt.Sprintf("the %s role cannot change %s", role, categoryTasks(path))}
+}
+
+// categoryTasks names the tasks filed under a category path in a refusal.
+func categoryTasks(path string) string {
+	if path == "" {
+		return "uncategorized tasks"
+	}
+	return fmt.Sprintf("tasks in category %q", path)
+}
+
+// categoryRole is the highest of the principal's own role and the roles
+// granted to them on the category or any of its ancestors.
+func categoryRole(ctx context.Context, db DBTX, principal *Principal, projectID int64, path string) (Role, error) {
+	role := principal.Role
+	if path == "" || role.Includes(RoleAdmin) {
+		return role, nil
+	}
+
+	args := []interface{}{principal.UserID, projectID}
+	segments := strings.Split(path, CategorySeparator)
+	for i := range segments {
+		args = append(args, strings.Join(segments[:i+1], CategorySeparator))
+	}
+
+	var granted []Role
+	if err := db.SelectContext(ctx, &granted, fmt.Sprintf(queryGrantedRoles, placeholders(len(segments))), args...); err != nil {
+		return "", err
+	}
+	for _, r := range granted {
+		if r.Includes(role) {
+			role = r
+		}
+	}
+	return role, nil
+}
+
+func GetCategoryPermissions(ctx context.Context, db DBTX, projectID int64, categoryID uint64) ([]CategoryPermission, error) {
+	permissions := []CategoryPermission{}
+	err := db.SelectContext(ctx, &permissions, queryGetCategoryPermissions, categoryID, projectID)
+	return permissions, err
+}
+
+// SetCategoryPermission grants the user a role on the category, replacing
+// any earlier grant there. It returns ErrUnknownUser or sql.ErrNoRows when
+// the user or the category does not exist.
+func SetCategoryPermission(ctx context.Context, db DBTX, projectID int64, categoryID uint64, userID int64, role Role) (*CategoryPermission, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+
+	var permission *CategoryPermission
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		category, err := GetCategoryByID(ctx, tx, projectID, categoryID)
+		if err != nil {
+			return err
+		}
+		if err := checkUsers(ctx, tx, null.NullInt64Of(userID)); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, querySetCategoryPermission, userID, categoryID, role); err != nil {
+			return err
+		}
+		permission = &CategoryPermission{UserID: userID, CategoryID: categoryID, CategoryPath: category.Path, Role: role}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return permission, nil
+}
+
+func DeleteCategoryPermission(ctx context.Context, db DBTX, projectID int64, categoryID uint64, userID int64) (int64, error) {
+	result, err := db.ExecContext(ctx, queryDeleteCategoryPermission, categoryID, userID, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,164 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+var (
+	viewer = &model.Principal{UserID: 3, Role: model.RoleViewer}
+	member = &model.Principal{UserID: 2, Role: model.RoleMember}
+	admin  = &model.Principal{UserID: 1, Role: model.RoleAdmin}
+)
+
+// expectTaskAccess answers the policy's lookup of a task in project 1.
+func expectTaskAccess(mockDB *model.MockDBTX, taskID int64, path *string, hasSubtasks bool) {
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{taskID, int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			task := dest.(*struct {
+				CategoryPath *string `db:"category_path"`
+				HasSubtasks  bool    `db:"has_subtasks"`
+			})
+			task.CategoryPath = path
+			task.HasSubtasks = hasSubtasks
+		}).
+		Return(nil).Once()
+}
+
+// expectGrants answers the lookup of the roles granted to a user on a
+// category path and its ancestors.
+func expectGrants(mockDB *model.MockDBTX, args []interface{}, roles ...model.Role) {
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, args).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]model.Role) = roles
+		}).
+		Return(nil).Once()
+}
+
+// expectSubtreeCategories answers the lookup of the category paths in the
+// subtree of a task of project 1.
+func expectSubtreeCategories(mockDB *model.MockDBTX, taskID int64, paths ...string) {
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{taskID, int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]string) = paths
+		}).
+		Return(nil).Once()
+}
+
+func forbidden(t *testing.T, err error) string {
+	var refused *model.ForbiddenError
+	assert.True(t, errors.As(err, &refused))
+	return refused.Reason
+}
+
+func TestRequireRole(t *testing.T) {
+	assert.NoError(t, model.RequireRole(admin, model.RoleAdmin))
+	assert.NoError(t, model.RequireRole(member, model.RoleViewer))
+	assert.Equal(t, "this requires the admin role, you are member", forbidden(t, model.RequireRole(member, model.RoleAdmin)))
+}
+
+func TestAuthorizeTask_ViewerCannotEdit(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	path := "Backend/Auth"
+
+	expectTaskAccess(mockDB, 1, &path, false)
+	expectGrants(mockDB, []interface{}{int64(3), int64(1), "Backend", "Backend/Auth"})
+
+	err := model.AuthorizeTask(context.Background(), mockDB, viewer, model.AccessEditTask, 1, 1)
+	assert.Equal(t, `the viewer role cannot change tasks in category "Backend/Auth"`, forbidden(t, err))
+}
+
+func TestAuthorizeTask_GrantOnAncestor(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	path := "Backend/Auth"
+
+	// A member grant on Backend holds for Backend/Auth.
+	expectTaskAccess(mockDB, 1, &path, false)
+	expectGrants(mockDB, []interface{}{int64(3), int64(1), "Backend", "Backend/Auth"}, model.RoleMember)
+
+	assert.NoError(t, model.AuthorizeTask(context.Background(), mockDB, viewer, model.AccessEditTask, 1, 1))
+}
+
+func TestAuthorizeTask_DeleteSubtree(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	expectTaskAccess(mockDB, 1, nil, true)
+	err := model.AuthorizeTask(context.Background(), mockDB, member, model.AccessDeleteTask, 1, 1)
+	assert.Equal(t, "only admins can delete a task that has subtasks, delete the subtasks first", forbidden(t, err))
+
+	// A leaf is the member's to delete, a subtree the admin's.
+	expectTaskAccess(mockDB, 2, nil, false)
+	assert.NoError(t, model.AuthorizeTask(context.Background(), mockDB, member, model.AccessDeleteTask, 1, 2))
+
+	expectTaskAccess(mockDB, 1, nil, true)
+	assert.NoError(t, model.AuthorizeTask(context.Background(), mockDB, admin, model.AccessDeleteTask, 1, 1))
+}
+
+func TestAuthorizeTask_DeleteSubtreeWithGrant(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	path := "Backend/Auth"
+
+	// An admin grant on Backend lets a member delete subtrees below it.
+	expectTaskAccess(mockDB, 1, &path, true)
+	expectGrants(mockDB, []interface{}{int64(2), int64(1), "Backend", "Backend/Auth"}, model.RoleAdmin)
+	expectSubtreeCategories(mockDB, 1, "Backend/Auth", "Backend/Auth/Tokens")
+	expectGrants(mockDB, []interface{}{int64(2), int64(1), "Backend", "Backend/Auth", "Backend/Auth/Tokens"}, model.RoleAdmin)
+	assert.NoError(t, model.AuthorizeTask(context.Background(), mockDB, member, model.AccessDeleteTask, 1, 1))
+
+	// A member grant does not.
+	expectTaskAccess(mockDB, 1, &path, true)
+	expectGrants(mockDB, []interface{}{int64(3), int64(1), "Backend", "Backend/Auth"}, model.RoleMember)
+	err := model.AuthorizeTask(context.Background(), mockDB, viewer, model.AccessDeleteTask, 1, 1)
+	assert.Equal(t, "only admins can delete a task that has subtasks, delete the subtasks first", forbidden(t, err))
+}
+
+func TestAuthorizeTask_DeleteSubtreeAcrossCategories(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	path := "Backend"
+
+	// The grant on Backend does not reach a subtask filed under Frontend,
+	// nor one left uncategorized.
+	expectTaskAccess(mockDB, 1, &path, true)
+	expectGrants(mockDB, []interface{}{int64(2), int64(1), "Backend"}, model.RoleAdmin)
+	expectSubtreeCategories(mockDB, 1, "", "Backend", "Frontend")
+	err := model.AuthorizeTask(context.Background(), mockDB, member, model.AccessDeleteTask, 1, 1)
+	assert.Equal(t, "the subtasks include uncategorized tasks, which only admins can delete", forbidden(t, err))
+
+	expectTaskAccess(mockDB, 1, &path, true)
+	expectGrants(mockDB, []interface{}{int64(2), int64(1), "Backend"}, model.RoleAdmin)
+	expectSubtreeCategories(mockDB, 1, "Backend", "Frontend")
+	expectGrants(mockDB, []interface{}{int64(2), int64(1), "Frontend"}, model.RoleMember)
+	err = model.AuthorizeTask(context.Background(), mockDB, member, model.AccessDeleteTask, 1, 1)
+	assert.Equal(t, `the subtasks include tasks in category "Frontend", which only admins can delete`, forbidden(t, err))
+
+	// An admin is not held back anywhere.
+	expectTaskAccess(mockDB, 1, &path, true)
+	assert.NoError(t, model.AuthorizeTask(context.Background(), mockDB, admin, model.AccessDeleteTask, 1, 1))
+}
+
+func TestAuthorizeTask_Missing(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The handler answers 404 for a task that does not exist.
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(99), int64(1)}).
+		Return(sql.ErrNoRows)
+
+	assert.NoError(t, model.AuthorizeTask(context.Background(), mockDB, member, model.AccessEditTask, 1, 99))
+}
+
+func TestAuthorizeCategory_AdminSkipsGrants(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	assert.NoError(t, model.AuthorizeCategory(context.Background(), mockDB, admin, 1, "Backend"))
+	assert.Equal(t, "the viewer role cannot change uncategorized tasks", forbidden(t, model.AuthorizeCategory(context.Background(), mockDB, viewer, 1, "")))
+}
@@ -0,0 +1,209 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// maxProjectName matches the width of projects.name.
+const maxProjectName = 100
+
+var (
+	ErrInvalidProjectName = errors.New("project names must be 1 to 100 characters")
+	ErrProjectExists      = errors.New("a project with this name already exists")
+)
+
+// Project owns a set of tasks and categories. Every task query is scoped to
+// one project, and a task can only be linked to tasks and categories of its
+// own project.
+type Project struct {
+	ID        int64     `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ProjectMember is a user let into a project. Admins are let into every
+// project without being listed.
+type ProjectMember struct {
+	UserID    int64     `db:"user_id" json:"user_id"`
+	Name      string    `db:"name" json:"name"`
+	Email     string    `db:"email" json:"email"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ProjectRequest creates or renames a project.
+type ProjectRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *ProjectRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" || utf8.RuneCountInString(r.Name) > maxProjectName {
+		return ErrInvalidProjectName
+	}
+	return nil
+}
+
+const (
+	queryGetProjects = `SELECT id, name, created_at FROM projects ORDER BY id`
+
+	queryGetProject = `SELECT id, name, created_at FROM projects WHERE id = ?`
+
+	queryGetMemberProjects = `
+		SELECT p.id, p.name, p.created_at
+		FROM projects p
+		INNER JOIN project_members m ON m.project_id = p.id
+		WHERE m.user_id = ?
+		ORDER BY p.id
+	`
+
+	queryGetMemberProject = `
+		SELECT p.id, p.name, p.created_at
+		FROM projects p
+		INNER JOIN project_members m ON m.project_id = p.id
+		WHERE p.id = ? AND m.user_id = ?
+	`
+
+	queryGetProjectMembers = `
+		SELECT m.user_id, u.name, u.email, m.created_at
+		FROM project_members m
+		INNER JOIN users u ON u.id = m.user_id
+		WHERE m.project_id = ?
+		ORDER BY m.user_id
+	`
+
+	queryAddProjectMember = `
+	INSERT INTO project_members (project_id, user_id) VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE project_id = project_id
+	`
+
+	queryRemoveProjectMember = `DELETE FROM project_members WHERE project_id = ? AND user_id = ?`
+
+	queryCreateProject = `INSERT INTO projects (name) VALUES (?)`
+
+	queryRenameProject = `UPDATE projects SET name = ? WHERE id = ?`
+
+	// The project's categories, tasks and task history go with it through
+	// the ON DELETE CASCADE on their project_id.
+	queryDeleteProject = `DELETE FROM projects WHERE id = ?`
+
+	queryLockProject = `SELECT id FROM projects WHERE id = ? FOR UPDATE`
+)
+
+// lockProject holds the project's row until the transaction ends. Changes to
+// the task tree and to the dependency graph take it first: each is checked
+// against the whole graph, and the check would not hold if another change
+// to the graph committed before the write. Task trees and dependencies never
+// leave a project, so the project is the smallest thing that covers them.
+func lockProject(ctx context.Context, tx DBTX, projectID int64) error {
+	var locked int64
+	return tx.GetContext(ctx, &locked, queryLockProject, projectID)
+}
+
+// GetProjects lists the projects the principal is a member of, or every
+// project for an admin or when principal is nil.
+func GetProjects(ctx context.Context, db DBTX, principal *Principal) ([]Project, error) {
+	projects := []Project{}
+	if principal == nil || principal.Role.Includes(RoleAdmin) {
+		err := db.SelectContext(ctx, &projects, queryGetProjects)
+		return projects, err
+	}
+	err := db.SelectContext(ctx, &projects, queryGetMemberProjects, principal.UserID)
+	return projects, err
+}
+
+func GetProjectByID(ctx context.Context, db DBTX, projectID int64) (*Project, error) {
+	var project Project
+	if err := db.GetContext(ctx, &project, queryGetProject, projectID); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetMemberProject returns the project if the principal may enter it. A
+// project the principal is not a member of gives sql.ErrNoRows, the same as
+// one that does not exist, so that other projects cannot be told apart from
+// missing ones.
+func GetMemberProject(ctx context.Context, db DBTX, principal *Principal, projectID int64) (*Project, error) {
+	if principal.Role.Includes(RoleAdmin) {
+		return GetProjectByID(ctx, db, projectID)
+	}
+
+	var project Project
+	if err := db.GetContext(ctx, &project, queryGetMemberProject, projectID, principal.UserID); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func GetProjectMembers(ctx context.Context, db DBTX, projectID int64) ([]ProjectMember, error) {
+	members := []ProjectMember{}
+	err := db.SelectContext(ctx, &members, queryGetProjectMembers, projectID)
+	return members, err
+}
+
+// AddProjectMember lets the user into the project; adding a member twice
+// changes nothing. It returns ErrUnknownUser or sql.ErrNoRows when the user
+// or the project does not exist.
+func AddProjectMember(ctx context.Context, db DBTX, projectID, userID int64) error {
+	return WithTx(ctx, db, func(tx DBTX) error {
+		if _, err := GetProjectByID(ctx, tx, projectID); err != nil {
+			return err
+		}
+		if err := checkUsers(ctx, tx, null.NullInt64Of(userID)); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, queryAddProjectMember, projectID, userID)
+		return err
+	})
+}
+
+func RemoveProjectMember(ctx context.Context, db DBTX, projectID, userID int64) (int64, error) {
+	result, err := db.ExecContext(ctx, queryRemoveProjectMember, projectID, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func CreateProject(ctx context.Context, db DBTX, req *ProjectRequest) (*Project, error) {
+	result, err := db.ExecContext(ctx, queryCreateProject, req.Name)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrProjectExists
+		}
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetProjectByID(ctx, db, id)
+}
+
+// RenameProject returns the project as it is afterwards, or sql.ErrNoRows
+// when there is no such project.
+func RenameProject(ctx context.Context, db DBTX, projectID int64, req *ProjectRequest) (*Project, error) {
+	if _, err := db.ExecContext(ctx, queryRenameProject, req.Name, projectID); err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrProjectExists
+		}
+		return nil, err
+	}
+	return GetProjectByID(ctx, db, projectID)
+}
+
+// DeleteProject removes a project with everything in it.
+func DeleteProject(ctx context.Context, db DBTX, projectID int64) (int64, error) {
+	result, err := db.ExecContext(ctx, queryDeleteProject, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
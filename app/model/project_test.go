@@ -0,0 +1,128 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/go-sql-driver/mysql"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestProjectRequest_Validate(t *testing.T) {
+	req := model.ProjectRequest{Name: "  Platform "}
+	assert.NoError(t, req.Validate())
+	assert.Equal(t, "Platform", req.Name)
+
+	for _, name := range []string{"", "   ", strings.Repeat("x", 101)} {
+		req := model.ProjectRequest{Name: name}
+		assert.Equal(t, model.ErrInvalidProjectName, req.Validate())
+	}
+}
+
+func TestCreateProject(t *testing.T) {
+	t.Run("created", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, []interface{}{"Platform"}).
+			Return(&mockResult{lastInsertID: 3, rowsAffected: 1}, nil)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(3)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*model.Project) = model.Project{ID: 3, Name: "Platform"}
+			}).
+			Return(nil)
+
+		project, err := model.CreateProject(context.Background(), mockDB, &model.ProjectRequest{Name: "Platform"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), project.ID)
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, mock.Anything).
+			Return(nil, &mysql.MySQLError{Number: 1062})
+
+		_, err := model.CreateProject(context.Background(), mockDB, &model.ProjectRequest{Name: "Platform"})
+		assert.Equal(t, model.ErrProjectExists, err)
+	})
+}
+
+func TestGetMemberProject(t *testing.T) {
+	t.Run("admin", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(2)}).
+			RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+				assert.False(t, strings.Contains(query, "project_members"))
+				*dest.(*model.Project) = model.Project{ID: 2, Name: "Mobile"}
+				return nil
+			})
+
+		admin := &model.Principal{UserID: 1, Role: model.RoleAdmin}
+		project, err := model.GetMemberProject(context.Background(), mockDB, admin, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "Mobile", project.Name)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(2), int64(5)}).
+			RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+				assert.True(t, strings.Contains(query, "m.user_id = ?"))
+				return sql.ErrNoRows
+			})
+
+		member := &model.Principal{UserID: 5, Role: model.RoleMember}
+		_, err := model.GetMemberProject(context.Background(), mockDB, member, 2)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestGetProjects_Member(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			assert.True(t, strings.Contains(query, "project_members"))
+			*dest.(*[]model.Project) = []model.Project{{ID: 1, Name: "Platform"}}
+			return nil
+		})
+
+	member := &model.Principal{UserID: 5, Role: model.RoleMember}
+	projects, err := model.GetProjects(context.Background(), mockDB, member)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(projects))
+}
+
+// expectProjectLock answers the lock that changes to the task tree or the
+// dependencies of project 1 take first.
+func expectProjectLock(mockDB *model.MockDBTX) {
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, "FROM projects") && strings.HasSuffix(query, "FOR UPDATE")
+		}), []interface{}{int64(1)}).
+		Return(nil).Once()
+}
+
+func TestValidateParent_OtherProject(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Task 3 belongs to another project, so the walk up from it finds
+	// nothing, exactly as for a task that does not exist.
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(3), int64(2)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			assert.True(t, strings.Contains(query, "project_id = ?"))
+			return sql.ErrNoRows
+		})
+
+	err := model.ValidateParent(context.Background(), mockDB, 2, 5, 3, 0)
+	assert.True(t, errors.Is(err, model.ErrParentNotFound))
+}
@@ -0,0 +1,391 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// Frequency is the FREQ of a recurrence rule.
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+const (
+	// maxRecurrence matches the width of tasks.recurrence.
+	maxRecurrence = 255
+	maxInterval   = 1000
+	// maxPeriods bounds the search for a month or year that has the wanted
+	// day, such as the 31st every other February.
+	maxPeriods = 1000
+	// MaxOccurrences is the most occurrences a preview lists.
+	MaxOccurrences = 100
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Recurrence is the subset of an RFC 5545 RRULE that tasks support: FREQ,
+// INTERVAL, COUNT, UNTIL, BYDAY for weekly rules and BYMONTHDAY for monthly
+// rules. Occurrences are dates, like due dates, and weeks start on Monday.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	// Count is how many occurrences are left, the current one included. 0
+	// means the series only ends at Until, if at all.
+	Count      int
+	Until      null.NullTime
+	ByDay      []time.Weekday
+	ByMonthDay []int
+}
+
+// ParseRecurrence reads a rule such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH.
+// Keys are case insensitive and an RRULE: prefix is allowed.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidRecurrence, fmt.Sprintf(format, args...))
+	}
+	if len(rule) > maxRecurrence {
+		return nil, invalid("longer than %d characters", maxRecurrence)
+	}
+
+	r := &Recurrence{Interval: 1}
+	seen := map[string]bool{}
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, invalid("%q is not KEY=VALUE", part)
+		}
+		if seen[key] {
+			return nil, invalid("%s is given twice", key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			switch r.Freq {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+			default:
+				err = invalid("unsupported FREQ %s", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 || r.Interval > maxInterval {
+				err = invalid("INTERVAL must be between 1 and %d", maxInterval)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				err = invalid("COUNT must be a positive number")
+			}
+		case "UNTIL":
+			// Only the date matters; a time of day is dropped.
+			until, parseErr := time.Parse("20060102", value[:min(len(value), 8)])
+			if parseErr != nil {
+				err = invalid("UNTIL must be a date such as 20261231")
+			}
+			r.Until = null.NullTimeOf(until)
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					err = invalid("unsupported BYDAY %s", day)
+					break
+				}
+				if !slices.Contains(r.ByDay, weekday) {
+					r.ByDay = append(r.ByDay, weekday)
+				}
+			}
+		case "BYMONTHDAY":
+			for _, raw := range strings.Split(value, ",") {
+				day, convErr := strconv.Atoi(raw)
+				if convErr != nil || day == 0 || day < -31 || day > 31 {
+					err = invalid("BYMONTHDAY must be between 1 and 31, or -31 and -1 from the end of the month")
+					break
+				}
+				if !slices.Contains(r.ByMonthDay, day) {
+					r.ByMonthDay = append(r.ByMonthDay, day)
+				}
+			}
+		default:
+			err = invalid("unsupported part %s", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return nil, invalid("FREQ is required")
+	case r.Count > 0 && r.Until.Valid():
+		return nil, invalid("COUNT and UNTIL cannot be combined")
+	case len(r.ByDay) > 0 && r.Freq != FreqWeekly:
+		return nil, invalid("BYDAY needs FREQ=WEEKLY")
+	case len(r.ByMonthDay) > 0 && r.Freq != FreqMonthly:
+		return nil, invalid("BYMONTHDAY needs FREQ=MONTHLY")
+	}
+	slices.SortFunc(r.ByDay, func(a, b time.Weekday) int { return daysSinceMonday(a) - daysSinceMonday(b) })
+	slices.Sort(r.ByMonthDay)
+	return r, nil
+}
+
+// String writes the rule back in RRULE form.
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, weekday := range r.ByDay {
+			days = append(days, strings.ToUpper(weekday.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until.Valid() {
+		parts = append(parts, "UNTIL="+r.Until.TimeValue().Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the first occurrence after the given occurrence, together
+// with the rule that continues the series from there. ok is false once the
+// series has ended.
+func (r *Recurrence) Next(after time.Time) (next time.Time, rest *Recurrence, ok bool) {
+	if r.Count == 1 {
+		return time.Time{}, nil, false
+	}
+
+	next, ok = r.following(dateOf(after))
+	if !ok || (r.Until.Valid() && next.After(dateOf(r.Until.TimeValue()))) {
+		return time.Time{}, nil, false
+	}
+
+	following := *r
+	if following.Count > 0 {
+		following.Count--
+	}
+	return next, &following, true
+}
+
+// Occurrences lists up to n occurrences that follow the given one.
+func (r *Recurrence) Occurrences(after time.Time, n int) []time.Time {
+	dates := []time.Time{}
+	for rule := r; len(dates) < n; {
+		next, rest, ok := rule.Next(after)
+		if !ok {
+			break
+		}
+		dates = append(dates, next)
+		rule, after = rest, next
+	}
+	return dates
+}
+
+func (r *Recurrence) following(day time.Time) (time.Time, bool) {
+	switch r.Freq {
+	case FreqDaily:
+		return day.AddDate(0, 0, r.Interval), true
+
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return day.AddDate(0, 0, 7*r.Interval), true
+		}
+		// Later days of the same week come first, then the first day of
+		// the next week in the series.
+		monday := day.AddDate(0, 0, -daysSinceMonday(day.Weekday()))
+		for _, weekday := range r.ByDay {
+			if offset := daysSinceMonday(weekday); offset > daysSinceMonday(day.Weekday()) {
+				return monday.AddDate(0, 0, offset), true
+			}
+		}
+		return monday.AddDate(0, 0, 7*r.Interval+daysSinceMonday(r.ByDay[0])), true
+
+	case FreqMonthly:
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{day.Day()}
+		}
+		first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for period := 0; period <= maxPeriods; period++ {
+			month := first.AddDate(0, period*r.Interval, 0)
+			last := month.AddDate(0, 1, -1).Day()
+			var found []int
+			for _, d := range days {
+				if d < 0 {
+					d = last + 1 + d
+				}
+				if d >= 1 && d <= last && (period > 0 || d > day.Day()) {
+					found = append(found, d)
+				}
+			}
+			if len(found) > 0 {
+				return month.AddDate(0, 0, slices.Min(found)-1), true
+			}
+		}
+
+	case FreqYearly:
+		// A rule started on February 29th only falls on leap years.
+		for period := 1; period <= maxPeriods; period++ {
+			year := day.Year() + period*r.Interval
+			next := time.Date(year, day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+			if next.Day() == day.Day() {
+				return next, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func daysSinceMonday(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
+
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// seriesAnchor is the occurrence the next one is counted from: the due date,
+// or the day the task was finished when it has none.
+func seriesAnchor(task *Task) time.Time {
+	switch {
+	case task.DueDate.Valid():
+		return dateOf(task.DueDate.TimeValue())
+	case task.CompletedAt.Valid():
+		return dateOf(task.CompletedAt.TimeValue())
+	}
+	return dateOf(time.Now().UTC())
+}
+
+// GetTaskOccurrences previews the due dates of up to n occurrences that
+// follow a task, or sql.ErrNoRows when the project has no such live task. A
+// task that does not recur has none.
+func GetTaskOccurrences(ctx context.Context, db DBTX, projectID, taskID int64, n int) ([]time.Time, error) {
+	task, err := GetByID(ctx, db, projectID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !task.Recurrence.Valid() {
+		return []time.Time{}, nil
+	}
+
+	rule, err := ParseRecurrence(task.Recurrence.StringValue())
+	if err != nil {
+		return nil, err
+	}
+	return rule.Occurrences(seriesAnchor(task), n), nil
+}
+
+const queryStopRecurrence = `UPDATE tasks SET recurrence = NULL WHERE id = ?`
+
+// spawnNextOccurrence creates the next occurrence of a recurring task that
+// was just finished, unless the series has ended. The copy is a fresh todo
+// task in the same place, with its due date and those of the copied
+// subtasks moved forward by the same number of days. Either way the old
+// task stops recurring, so that reopening and finishing it again does not
+// start a second series.
+func spawnNextOccurrence(ctx context.Context, db DBTX, projectID int64, task *Task, opts WriteOptions) error {
+	rule, err := ParseRecurrence(task.Recurrence.StringValue())
+	if err != nil {
+		return err
+	}
+	anchor := seriesAnchor(task)
+	if next, rest, ok := rule.Next(anchor); ok {
+		if err := createOccurrence(ctx, db, projectID, task, next, rest, int(next.Sub(anchor).Hours()/24), opts); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.ExecContext(ctx, queryStopRecurrence, task.ID)
+	return err
+}
+
+func createOccurrence(ctx context.Context, db DBTX, projectID int64, task *Task, next time.Time, rule *Recurrence, shift int, opts WriteOptions) error {
+	tree, err := GetTaskWithSubtasks(ctx, db, projectID, task.ID)
+	if err != nil {
+		return err
+	}
+	if !task.RecurSubtasks {
+		tree.Subtasks = nil
+	}
+
+	root := occurrenceRequest(tree, shift)
+	root.DueDate = null.NullTimeOf(next)
+	root.ParentTaskID = task.ParentTaskID
+	root.Recurrence = null.NullStringOf(rule.String())
+	root.RecurSubtasks = task.RecurSubtasks
+
+	created, err := CreateTask(ctx, db, projectID, root, opts)
+	if err != nil {
+		return err
+	}
+	return cloneSubtasks(ctx, db, projectID, tree.Subtasks, created.ID, shift, opts)
+}
+
+func cloneSubtasks(ctx context.Context, db DBTX, projectID int64, subtasks []TaskHierarchy, parentID int64, shift int, opts WriteOptions) error {
+	for i := range subtasks {
+		req := occurrenceRequest(&subtasks[i], shift)
+		req.ParentTaskID = null.NullInt64Of(parentID)
+
+		created, err := CreateTask(ctx, db, projectID, req, opts)
+		if err != nil {
+			return err
+		}
+		if err := cloneSubtasks(ctx, db, projectID, subtasks[i].Subtasks, created.ID, shift, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// occurrenceRequest copies what a task is about, leaving out its progress.
+// Subtasks do not keep a rule of their own.
+func occurrenceRequest(task *TaskHierarchy, shift int) *CreateTaskRequest {
+	status := StatusTodo
+	req := &CreateTaskRequest{
+		Title:         task.Title,
+		Description:   task.Description,
+		Status:        &status,
+		Priority:      task.Priority,
+		EstimateHours: task.EstimateHours,
+		AssigneeID:    task.AssigneeID,
+		ReporterID:    task.ReporterID,
+		Tags:          task.Tags,
+	}
+	if task.DueDate.Valid() {
+		req.DueDate = null.NullTimeOf(task.DueDate.TimeValue().AddDate(0, 0, shift))
+	}
+	if task.CategoryName != nil {
+		req.CategoryName = null.NullStringOf(*task.CategoryName)
+	}
+	return req
+}
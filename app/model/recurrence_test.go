@@ -0,0 +1,151 @@
+package model_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRecurrence(t *testing.T) {
+	t.Run("normalized", func(t *testing.T) {
+		rule, err := model.ParseRecurrence("rrule:freq=weekly;byday=th,mo,su;interval=2")
+		assert.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH,SU", rule.String())
+	})
+
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20261231",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYSETPOS=-1",
+	} {
+		t.Run(rule, func(t *testing.T) {
+			_, err := model.ParseRecurrence(rule)
+			assert.True(t, errors.Is(err, model.ErrInvalidRecurrence))
+		})
+	}
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	for _, tc := range []struct {
+		rule  string
+		after time.Time
+		want  []time.Time
+	}{
+		{"FREQ=DAILY;INTERVAL=3", date(2026, 2, 27), []time.Time{date(2026, 3, 2), date(2026, 3, 5), date(2026, 3, 8)}},
+		// 2026-03-04 is a Wednesday.
+		{"FREQ=WEEKLY;BYDAY=MO,FR", date(2026, 3, 4), []time.Time{date(2026, 3, 6), date(2026, 3, 9), date(2026, 3, 13)}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR", date(2026, 3, 6), []time.Time{date(2026, 3, 16), date(2026, 3, 20), date(2026, 3, 30)}},
+		{"FREQ=MONTHLY", date(2026, 1, 31), []time.Time{date(2026, 3, 31), date(2026, 5, 31), date(2026, 7, 31)}},
+		{"FREQ=MONTHLY;BYMONTHDAY=1,-1", date(2026, 2, 1), []time.Time{date(2026, 2, 28), date(2026, 3, 1), date(2026, 3, 31)}},
+		{"FREQ=YEARLY", date(2028, 2, 29), []time.Time{date(2032, 2, 29), date(2036, 2, 29), date(2040, 2, 29)}},
+		{"FREQ=WEEKLY;COUNT=3", date(2026, 3, 2), []time.Time{date(2026, 3, 9), date(2026, 3, 16)}},
+		{"FREQ=WEEKLY;UNTIL=20260316T120000Z", date(2026, 3, 2), []time.Time{date(2026, 3, 9), date(2026, 3, 16)}},
+	} {
+		t.Run(tc.rule, func(t *testing.T) {
+			rule, err := model.ParseRecurrence(tc.rule)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, rule.Occurrences(tc.after, 3))
+		})
+	}
+}
+
+func TestUpdateTask_NextOccurrence(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	status := model.StatusDone
+	req := &model.UpdateTaskRequest{Status: &status}
+
+	current := model.Task{
+		ID:         8,
+		Title:      "Weekly review",
+		Status:     model.StatusInProgress,
+		DueDate:    nulltype.NullTimeOf(date(2026, 3, 2)),
+		Recurrence: nulltype.NullStringOf("FREQ=WEEKLY;COUNT=4"),
+	}
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(8), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = current
+		}).
+		Return(nil).Twice()
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(8)}).
+		Return(nil).Once()
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.HasPrefix(query, "UPDATE tasks")
+		}), mock.Anything).
+		Run(func(ctx context.Context, query string, arg interface{}) {
+			current.Status = model.StatusDone
+		}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+
+	// The whole tree is read to copy it, subtasks included or not.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(8), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]model.TaskHierarchy) = []model.TaskHierarchy{{Task: current}}
+		}).
+		Return(nil).Once()
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, arg interface{}) {
+			values := arg.(map[string]interface{})
+			assert.Equal(t, "Weekly review", values["title"])
+			assert.Equal(t, nulltype.NullTimeOf(date(2026, 3, 9)), values["due_date"])
+			assert.Equal(t, nulltype.NullStringOf("FREQ=WEEKLY;COUNT=3"), values["recurrence"])
+		}).
+		Return(&mockResult{lastInsertID: 9}, nil).Once()
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(9), int64(1)}).
+		Return(nil).Once()
+
+	// The history and outbox event of the new task, then the old task stops
+	// recurring and records both changes.
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{lastInsertID: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.EventTaskCreated, args[2])
+		}).
+		Return(&mockResult{}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(8)}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			changes := args[4].(model.Changes)
+			assert.Equal(t, model.FieldChange{From: "FREQ=WEEKLY;COUNT=4", To: nil}, changes["recurrence"])
+		}).
+		Return(&mockResult{lastInsertID: 2}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.EventTaskCompleted, args[2])
+		}).
+		Return(&mockResult{}, nil).Once()
+
+	affected, err := model.UpdateTask(context.Background(), mockDB, 1, 8, req, model.WriteOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// ReminderOverdue names the reminder sent once a task is past its due date.
+const ReminderOverdue = "overdue"
+
+// Reminder tells whoever works on a task that its due date is near, or has
+// passed. A task is due by the end of its due date.
+type Reminder struct {
+	TaskID    int64     `json:"task_id" db:"id"`
+	ProjectID int64     `json:"project_id" db:"project_id"`
+	Title     string    `json:"title" db:"title"`
+	DueDate   time.Time `json:"due_date" db:"due_date"`
+	// Name is ReminderOverdue or the lead time, such as 24h0m0s.
+	Name          string          `json:"reminder" db:"-"`
+	Lead          time.Duration   `json:"-" db:"-"`
+	AssigneeName  null.NullString `json:"assignee_name" db:"assignee_name"`
+	AssigneeEmail null.NullString `json:"assignee_email" db:"assignee_email"`
+}
+
+// Overdue reports whether the reminder is for a task past its due date.
+func (r *Reminder) Overdue() bool {
+	return r.Lead == 0
+}
+
+// ReminderName is how a reminder with the given lead time is recorded.
+func ReminderName(lead time.Duration) string {
+	if lead <= 0 {
+		return ReminderOverdue
+	}
+	return lead.String()
+}
+
+const (
+	// queryDueReminders finds the open tasks whose reminder has not gone out
+	// for their current due date. It is completed with the range of due
+	// dates the reminder covers.
+	queryDueReminders = `
+		SELECT t.id, t.project_id, t.title, t.due_date,
+			u.name AS assignee_name, u.email AS assignee_email
+		FROM tasks t
+		LEFT JOIN users u ON u.id = t.assignee_id
+		LEFT JOIN task_reminders r
+			ON r.task_id = t.id AND r.due_date = t.due_date AND r.reminder = ?
+		WHERE t.deleted_at IS NULL AND t.status <> 'done' AND r.task_id IS NULL
+	`
+
+	queryClaimReminder = `
+	INSERT INTO task_reminders (task_id, due_date, reminder) VALUES (?, ?, ?)
+	ON DUPLICATE KEY UPDATE fired_at = fired_at
+	`
+
+	queryReleaseReminder = `DELETE FROM task_reminders WHERE task_id = ? AND due_date = ? AND reminder = ?`
+)
+
+// FindDueReminders lists up to limit reminders with the given lead time that
+// should have gone out by now. Reminders ahead of the due date are only
+// sent while it is still ahead, so a task that is already overdue only gets
+// the overdue reminder.
+func FindDueReminders(ctx context.Context, db DBTX, lead time.Duration, now time.Time, limit int) ([]Reminder, error) {
+	name := ReminderName(lead)
+
+	// A due date ends a day after it starts; the reminder is due lead
+	// before that.
+	query := queryDueReminders + " AND t.due_date <= ?"
+	args := []interface{}{name, now.Add(lead - 24*time.Hour)}
+	if lead > 0 {
+		query += " AND t.due_date > ?"
+		args = append(args, now.Add(-24*time.Hour))
+	}
+	query += " ORDER BY t.due_date, t.id LIMIT ?"
+	args = append(args, limit)
+
+	var reminders []Reminder
+	if err := db.SelectContext(ctx, &reminders, query, args...); err != nil {
+		return nil, err
+	}
+	for i := range reminders {
+		reminders[i].Name = name
+		reminders[i].Lead = max(lead, 0)
+	}
+	return reminders, nil
+}
+
+// ClaimReminder records that a reminder is going out. It reports false when
+// it already had, which keeps a reminder from firing twice even with several
+// schedulers running.
+func ClaimReminder(ctx context.Context, db DBTX, reminder *Reminder) (bool, error) {
+	res, err := db.ExecContext(ctx, queryClaimReminder, reminder.TaskID, reminder.DueDate, reminder.Name)
+	if err != nil {
+		return false, err
+	}
+	// ON DUPLICATE KEY UPDATE counts a row that was left as it was as 0.
+	claimed, err := res.RowsAffected()
+	return claimed == 1, err
+}
+
+// ReleaseReminder forgets a claimed reminder that could not be delivered,
+// so that the next scan tries it again.
+func ReleaseReminder(ctx context.Context, db DBTX, reminder *Reminder) error {
+	_, err := db.ExecContext(ctx, queryReleaseReminder, reminder.TaskID, reminder.DueDate, reminder.Name)
+	return err
+}
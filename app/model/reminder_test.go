@@ -0,0 +1,74 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestFindDueReminders(t *testing.T) {
+	now := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	t.Run("ahead of the due date", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				assert.True(t, strings.Contains(query, "t.due_date <= ? AND t.due_date > ?"))
+				// Due by the end of March 3rd at the latest, and not over yet.
+				assert.Equal(t, []interface{}{"48h0m0s", now.Add(24 * time.Hour), now.Add(-24 * time.Hour), 100}, args)
+				*dest.(*[]model.Reminder) = []model.Reminder{{TaskID: 4}}
+			}).
+			Return(nil)
+
+		reminders, err := model.FindDueReminders(context.Background(), mockDB, 48*time.Hour, now, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(reminders))
+		assert.Equal(t, "48h0m0s", reminders[0].Name)
+		assert.False(t, reminders[0].Overdue())
+	})
+
+	t.Run("overdue", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				assert.Equal(t, []interface{}{model.ReminderOverdue, now.Add(-24 * time.Hour), 100}, args)
+				*dest.(*[]model.Reminder) = []model.Reminder{{TaskID: 3}}
+			}).
+			Return(nil)
+
+		reminders, err := model.FindDueReminders(context.Background(), mockDB, 0, now, 100)
+		assert.NoError(t, err)
+		assert.True(t, reminders[0].Overdue())
+	})
+}
+
+func TestClaimReminder(t *testing.T) {
+	reminder := &model.Reminder{TaskID: 4, DueDate: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), Name: "24h0m0s"}
+
+	for _, tc := range []struct {
+		name    string
+		rows    int64
+		claimed bool
+	}{
+		{"first time", 1, true},
+		{"already sent", 0, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB := model.NewMockDBTX(t)
+			mockDB.EXPECT().
+				ExecContext(mock.Anything, mock.Anything, []interface{}{int64(4), reminder.DueDate, "24h0m0s"}).
+				Return(&mockResult{rowsAffected: tc.rows}, nil)
+
+			claimed, err := model.ClaimReminder(context.Background(), mockDB, reminder)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.claimed, claimed)
+		})
+	}
+}
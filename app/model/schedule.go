@@ -0,0 +1,234 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+var ErrScheduleCycle = errors.New("dependencies and subtasks form a loop, the tasks cannot be scheduled")
+
+// TaskSchedule places a task on a schedule. A task can start once its
+// blockers and its subtasks are finished, and takes its estimate_hours;
+// tasks that are done or have no estimate take no time.
+type TaskSchedule struct {
+	EarliestStart  time.Time `json:"earliest_start"`
+	EarliestFinish time.Time `json:"earliest_finish"`
+	LatestStart    time.Time `json:"latest_start"`
+	LatestFinish   time.Time `json:"latest_finish"`
+	// SlackHours is how long the task can slip without delaying the end of
+	// the schedule.
+	SlackHours float64 `json:"slack_hours"`
+	Critical   bool    `json:"critical"`
+	// Late is set when the task cannot be finished by the end of its due
+	// date, even if it starts as early as its predecessors allow.
+	Late bool `json:"late"`
+}
+
+// Schedule is the critical path computation over a set of tasks, nested as
+// subtasks the way GetTaskWithSubtasks returns them.
+type Schedule struct {
+	Start  time.Time `json:"start"`
+	Finish time.Time `json:"finish"`
+	// CriticalPath lists the tasks, first to last, that decide Finish.
+	CriticalPath []int64         `json:"critical_path"`
+	Late         []int64         `json:"late"`
+	Tasks        []TaskHierarchy `json:"tasks"`
+}
+
+const (
+	queryGetProjectTasks = `
+		SELECT
+			t.id, t.project_id, t.title, t.description, t.status, t.priority,
+			t.due_date, t.estimate_hours, t.recurrence, t.recur_subtasks, t.completed_at, t.parent_task_id, t.position, t.category_id,
+			t.assignee_id, t.reporter_id,
+			t.created_at, t.updated_at, t.deleted_at, c.path as category_name,
+			` + queryTagsOf + `t.id) AS tags
+		FROM tasks t
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE t.project_id = ? AND t.deleted_at IS NULL
+		ORDER BY t.position ASC, t.priority DESC, t.created_at ASC
+	`
+
+	queryGetProjectDependencies = `
+		SELECT d.blocker_id, d.blocked_id
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.blocked_id
+		WHERE t.project_id = ?
+	`
+)
+
+// GetProjectSchedule schedules every live task of the project from start.
+func GetProjectSchedule(ctx context.Context, db DBTX, projectID int64, start time.Time) (*Schedule, error) {
+	var tasks []TaskHierarchy
+	if err := db.SelectContext(ctx, &tasks, queryGetProjectTasks, projectID); err != nil {
+		return nil, err
+	}
+	return scheduleTasks(ctx, db, projectID, tasks, start)
+}
+
+// GetTaskSchedule schedules a task and its live descendants from start.
+// Dependencies on tasks outside the subtree are left out. It returns
+// sql.ErrNoRows when the project has no such live task.
+func GetTaskSchedule(ctx context.Context, db DBTX, projectID, taskID int64, start time.Time) (*Schedule, error) {
+	tasks, err := getTaskHierarchy(ctx, db, projectID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return scheduleTasks(ctx, db, projectID, tasks, start)
+}
+
+func scheduleTasks(ctx context.Context, db DBTX, projectID int64, tasks []TaskHierarchy, start time.Time) (*Schedule, error) {
+	var deps []TaskDependency
+	if err := db.SelectContext(ctx, &deps, queryGetProjectDependencies, projectID); err != nil {
+		return nil, err
+	}
+
+	schedule, err := computeSchedule(tasks, deps, start)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Tasks = buildTaskTrees(tasks)
+	return schedule, nil
+}
+
+// computeSchedule runs the forward and backward passes of the critical path
+// method over tasks and fills in their Schedule. A subtask comes before its
+// parent; edges to tasks that are not in the list are ignored.
+func computeSchedule(tasks []TaskHierarchy, deps []TaskDependency, start time.Time) (*Schedule, error) {
+	index := make(map[int64]int, len(tasks))
+	for i := range tasks {
+		index[tasks[i].ID] = i
+	}
+
+	preds := make([][]int, len(tasks))
+	succs := make([][]int, len(tasks))
+	link := func(from, to int64) {
+		u, fromOK := index[from]
+		v, toOK := index[to]
+		if fromOK && toOK {
+			preds[v] = append(preds[v], u)
+			succs[u] = append(succs[u], v)
+		}
+	}
+	for i := range tasks {
+		if tasks[i].ParentTaskID.Valid() {
+			link(tasks[i].ID, tasks[i].ParentTaskID.Int64Value())
+		}
+	}
+	for _, dep := range deps {
+		link(dep.BlockerID, dep.BlockedID)
+	}
+
+	order, err := topologicalOrder(tasks, preds, succs)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := make([]time.Duration, len(tasks))
+	for i := range tasks {
+		if tasks[i].Status != StatusDone && tasks[i].EstimateHours.Valid() {
+			duration[i] = time.Duration(math.Round(tasks[i].EstimateHours.Float64Value()*3600)) * time.Second
+		}
+	}
+
+	// Forward pass: a task starts when the last of its predecessors ends.
+	es := make([]time.Duration, len(tasks))
+	ef := make([]time.Duration, len(tasks))
+	var finish time.Duration
+	for _, v := range order {
+		for _, u := range preds[v] {
+			es[v] = max(es[v], ef[u])
+		}
+		ef[v] = es[v] + duration[v]
+		finish = max(finish, ef[v])
+	}
+
+	// Backward pass: a task must end before the first of its successors
+	// has to start.
+	ls := make([]time.Duration, len(tasks))
+	lf := make([]time.Duration, len(tasks))
+	for i := len(order) - 1; i >= 0; i-- {
+		v := order[i]
+		lf[v] = finish
+		for _, w := range succs[v] {
+			lf[v] = min(lf[v], ls[w])
+		}
+		ls[v] = lf[v] - duration[v]
+	}
+
+	schedule := &Schedule{
+		Start:        start,
+		Finish:       start.Add(finish),
+		CriticalPath: []int64{},
+		Late:         []int64{},
+	}
+	last := -1
+	for _, v := range order {
+		task := &tasks[v]
+		task.Schedule = &TaskSchedule{
+			EarliestStart:  start.Add(es[v]),
+			EarliestFinish: start.Add(ef[v]),
+			LatestStart:    start.Add(ls[v]),
+			LatestFinish:   start.Add(lf[v]),
+			SlackHours:     (ls[v] - es[v]).Hours(),
+			Critical:       ls[v] == es[v],
+		}
+		// A due date is met as long as the task ends within that day.
+		if task.DueDate.Valid() && start.Add(ef[v]).After(task.DueDate.TimeValue().AddDate(0, 0, 1)) {
+			task.Schedule.Late = true
+			schedule.Late = append(schedule.Late, task.ID)
+		}
+		if task.Schedule.Critical && ef[v] == finish && last == -1 {
+			last = v
+		}
+	}
+	sort.Slice(schedule.Late, func(i, j int) bool { return schedule.Late[i] < schedule.Late[j] })
+
+	// Walk back from the task that ends last through predecessors that
+	// leave it no slack.
+	for v := last; v != -1; {
+		schedule.CriticalPath = append([]int64{tasks[v].ID}, schedule.CriticalPath...)
+		next := -1
+		for _, u := range preds[v] {
+			if ef[u] == es[v] && ls[u] == es[u] && (next == -1 || tasks[u].ID < tasks[next].ID) {
+				next = u
+			}
+		}
+		v = next
+	}
+	return schedule, nil
+}
+
+// topologicalOrder sorts the tasks so that each comes after all of its
+// predecessors, lowest id first among those that are ready. It returns
+// ErrScheduleCycle when there is no such order.
+func topologicalOrder(tasks []TaskHierarchy, preds, succs [][]int) ([]int, error) {
+	pending := make([]int, len(tasks))
+	var ready []int
+	for v := range tasks {
+		pending[v] = len(preds[v])
+		if pending[v] == 0 {
+			ready = append(ready, v)
+		}
+	}
+
+	order := make([]int, 0, len(tasks))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return tasks[ready[i]].ID < tasks[ready[j]].ID })
+		v := ready[0]
+		ready = ready[1:]
+		order = append(order, v)
+		for _, w := range succs[v] {
+			if pending[w]--; pending[w] == 0 {
+				ready = append(ready, w)
+			}
+		}
+	}
+	if len(order) != len(tasks) {
+		return nil, ErrScheduleCycle
+	}
+	return order, nil
+}
@@ -0,0 +1,103 @@
+package model_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+var scheduleStart = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// expectProjectGraph answers the two queries of GetProjectSchedule.
+func expectProjectGraph(mockDB *model.MockDBTX, tasks []model.TaskHierarchy, deps []model.TaskDependency) {
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]model.TaskHierarchy) = tasks
+		}).
+		Return(nil).Once()
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]model.TaskDependency) = deps
+		}).
+		Return(nil).Once()
+}
+
+func estimated(id int64, hours float64) model.TaskHierarchy {
+	return model.TaskHierarchy{Task: model.Task{ID: id, Status: model.StatusTodo, EstimateHours: nulltype.NullFloat64Of(hours)}}
+}
+
+func TestGetProjectSchedule(t *testing.T) {
+	t.Run("critical path", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		// 5 (done) blocks 1 (4h), which blocks 2 (8h) and 3 (2h). Task 4
+		// (3h) is a subtask of 2, so 2 waits on both 1 and 4.
+		sub := estimated(4, 3)
+		sub.ParentTaskID = nulltype.NullInt64Of(2)
+		done := estimated(5, 40)
+		done.Status = model.StatusDone
+		expectProjectGraph(mockDB,
+			[]model.TaskHierarchy{estimated(1, 4), estimated(2, 8), estimated(3, 2), sub, done},
+			[]model.TaskDependency{{BlockerID: 1, BlockedID: 2}, {BlockerID: 1, BlockedID: 3}, {BlockerID: 5, BlockedID: 1}})
+
+		schedule, err := model.GetProjectSchedule(context.Background(), mockDB, 1, scheduleStart)
+		assert.NoError(t, err)
+		assert.Equal(t, scheduleStart.Add(12*time.Hour), schedule.Finish)
+		assert.Equal(t, []int64{5, 1, 2}, schedule.CriticalPath)
+		assert.Equal(t, []int64{}, schedule.Late)
+
+		byID := map[int64]*model.TaskSchedule{}
+		var walk func([]model.TaskHierarchy)
+		walk = func(tasks []model.TaskHierarchy) {
+			for _, task := range tasks {
+				byID[task.ID] = task.Schedule
+				walk(task.Subtasks)
+			}
+		}
+		walk(schedule.Tasks)
+		assert.Equal(t, 5, len(byID))
+
+		assert.Equal(t, scheduleStart.Add(4*time.Hour), byID[2].EarliestStart)
+		assert.True(t, byID[2].Critical)
+		assert.Equal(t, scheduleStart.Add(4*time.Hour), byID[3].EarliestStart)
+		assert.Equal(t, 6.0, byID[3].SlackHours)
+		assert.False(t, byID[3].Critical)
+		assert.Equal(t, 1.0, byID[4].SlackHours)
+		assert.Equal(t, scheduleStart.Add(time.Hour), byID[4].LatestStart)
+	})
+
+	t.Run("late", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		first := estimated(1, 30)
+		second := estimated(2, 1)
+		second.DueDate = nulltype.NullTimeOf(scheduleStart.Truncate(24 * time.Hour))
+		expectProjectGraph(mockDB,
+			[]model.TaskHierarchy{first, second},
+			[]model.TaskDependency{{BlockerID: 1, BlockedID: 2}})
+
+		schedule, err := model.GetProjectSchedule(context.Background(), mockDB, 1, scheduleStart)
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{2}, schedule.Late)
+		assert.True(t, schedule.Tasks[1].Schedule.Late)
+		assert.False(t, schedule.Tasks[0].Schedule.Late)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		// 2 is a subtask of 1, yet it waits on 1.
+		sub := estimated(2, 1)
+		sub.ParentTaskID = nulltype.NullInt64Of(1)
+		expectProjectGraph(mockDB,
+			[]model.TaskHierarchy{estimated(1, 1), sub},
+			[]model.TaskDependency{{BlockerID: 1, BlockedID: 2}})
+
+		_, err := model.GetProjectSchedule(context.Background(), mockDB, 1, scheduleStart)
+		assert.Equal(t, model.ErrScheduleCycle, err)
+	})
+}
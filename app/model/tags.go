@@ -0,0 +1,166 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxTagName matches the width of tags.name.
+	maxTagName = 50
+	// MaxTaskTags keeps a task's tags well inside the GROUP_CONCAT limit
+	// they are read back with.
+	MaxTaskTags = 20
+)
+
+var (
+	ErrInvalidTag  = errors.New("tags must be 1 to 50 characters without commas")
+	ErrTooManyTags = fmt.Errorf("a task can have at most %d tags", MaxTaskTags)
+)
+
+// TagList is a task's tags, sorted by name. It scans the comma separated
+// list built by GROUP_CONCAT and is always a JSON array.
+type TagList []string
+
+func (l *TagList) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into TagList", value)
+	}
+	*l = strings.Split(raw, ",")
+	return nil
+}
+
+func (l TagList) MarshalJSON() ([]byte, error) {
+	if l == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]string(l))
+}
+
+// CleanTags trims and lower-cases tags, so that "Q3" and "q3 " are the same
+// label, and drops duplicates.
+func CleanTags(tags []string) (TagList, error) {
+	seen := make(map[string]bool, len(tags))
+	clean := TagList{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || utf8.RuneCountInString(tag) > maxTagName || strings.Contains(tag, ",") {
+			return nil, ErrInvalidTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			clean = append(clean, tag)
+		}
+	}
+	if len(clean) > MaxTaskTags {
+		return nil, ErrTooManyTags
+	}
+	sort.Strings(clean)
+	return clean, nil
+}
+
+const (
+	// queryTagsOf is a column listing a task's tags. It is completed with the
+	// task id column and a closing parenthesis.
+	queryTagsOf = `(
+			SELECT GROUP_CONCAT(tg.name ORDER BY tg.name SEPARATOR ',')
+			FROM task_tags tt
+			INNER JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tt.task_id = `
+
+	// queryTaskHasTags matches tasks carrying any of the listed tags. It is
+	// completed with their placeholders.
+	queryTaskHasTags = `
+		SELECT 1
+		FROM task_tags tt
+		INNER JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.task_id = t.id AND tg.name IN (%s)
+	`
+
+	// queryCountTaskTags counts how many of the listed tags a task carries.
+	queryCountTaskTags = `
+		SELECT COUNT(*)
+		FROM task_tags tt
+		INNER JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.task_id = t.id AND tg.name IN (%s)
+	`
+
+	queryGetTaskTags = `
+		SELECT tg.name
+		FROM task_tags tt
+		INNER JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.task_id = ?
+		ORDER BY tg.name
+	`
+
+	queryClearTaskTags = `DELETE FROM task_tags WHERE task_id = ?`
+)
+
+// tagConditions adds the any-of, all-of and none-of tag filters.
+func (f *TaskFilter) tagConditions(conds []string, args []interface{}) ([]string, []interface{}) {
+	if len(f.TagsAny) > 0 {
+		conds = append(conds, "EXISTS ("+fmt.Sprintf(queryTaskHasTags, placeholders(len(f.TagsAny)))+")")
+		args = appendTags(args, f.TagsAny)
+	}
+	if len(f.TagsAll) > 0 {
+		conds = append(conds, "("+fmt.Sprintf(queryCountTaskTags, placeholders(len(f.TagsAll)))+") = ?")
+		args = appendTags(args, f.TagsAll)
+		args = append(args, len(f.TagsAll))
+	}
+	if len(f.TagsNone) > 0 {
+		conds = append(conds, "NOT EXISTS ("+fmt.Sprintf(queryTaskHasTags, placeholders(len(f.TagsNone)))+")")
+		args = appendTags(args, f.TagsNone)
+	}
+	return conds, args
+}
+
+func appendTags(args []interface{}, tags []string) []interface{} {
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	return args
+}
+
+// GetTaskTags returns a task's tags by name.
+func GetTaskTags(ctx context.Context, db DBTX, taskID int64) (TagList, error) {
+	var names []string
+	if err := db.SelectContext(ctx, &names, queryGetTaskTags, taskID); err != nil {
+		return nil, err
+	}
+	return append(TagList{}, names...), nil
+}
+
+// setTaskTags replaces a task's tags with a clean list, creating the tags
+// that do not exist yet.
+func setTaskTags(ctx context.Context, db DBTX, taskID int64, tags TagList) error {
+	if _, err := db.ExecContext(ctx, queryClearTaskTags, taskID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?), ", len(tags)), ", ")
+	args := appendTags(nil, tags)
+	if _, err := db.ExecContext(ctx, "INSERT INTO tags (name) VALUES "+values+" ON DUPLICATE KEY UPDATE id = id", args...); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO task_tags (task_id, tag_id) SELECT ?, id FROM tags WHERE name IN (" + placeholders(len(tags)) + ")"
+	_, err := db.ExecContext(ctx, query, append([]interface{}{taskID}, args...)...)
+	return err
+}
@@ -0,0 +1,117 @@
+package model_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestCleanTags(t *testing.T) {
+	tags, err := model.CleanTags([]string{" Q3", "tech-debt", "q3 ", "Customer-Facing"})
+	assert.NoError(t, err)
+	assert.Equal(t, model.TagList{"customer-facing", "q3", "tech-debt"}, tags)
+
+	for _, bad := range [][]string{{""}, {"  "}, {"a,b"}, {strings.Repeat("x", 51)}} {
+		_, err := model.CleanTags(bad)
+		assert.Equal(t, model.ErrInvalidTag, err)
+	}
+
+	many := make([]string, model.MaxTaskTags+1)
+	for i := range many {
+		many[i] = strings.Repeat("t", i+1)
+	}
+	_, err = model.CleanTags(many)
+	assert.Equal(t, model.ErrTooManyTags, err)
+}
+
+func TestTagList_ScanAndJSON(t *testing.T) {
+	var tags model.TagList
+	assert.NoError(t, tags.Scan([]byte("q3,tech-debt")))
+	assert.Equal(t, model.TagList{"q3", "tech-debt"}, tags)
+
+	assert.NoError(t, tags.Scan(nil))
+	data, err := json.Marshal(tags)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+}
+
+func TestGetAllTasks_TagFilters(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	filter := &model.TaskFilter{
+		ProjectID: 1,
+		TagsAny:   []string{"q3", "q4"},
+		TagsAll:   []string{"customer-facing"},
+		TagsNone:  []string{"blocked"},
+	}
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{
+			int64(1), "q3", "q4", "customer-facing", 1, "blocked", model.DefaultTaskLimit, 0,
+		}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "EXISTS (\n\t\tSELECT 1"))
+			assert.True(t, strings.Contains(query, "tg.name IN (?, ?)"))
+			assert.True(t, strings.Contains(query, ") = ?"))
+			assert.True(t, strings.Contains(query, "NOT EXISTS ("))
+		}).
+		Return(nil)
+
+	_, err := model.GetAllTasks(context.Background(), mockDB, filter)
+	assert.NoError(t, err)
+}
+
+func TestUpdateTask_TagsOnly(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	var req model.UpdateTaskRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{"tags": ["Q3", "blocked"]}`), &req))
+	assert.False(t, req.IsEmpty())
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 1, Title: "Task", Status: model.StatusTodo}
+		}).
+		Return(nil)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]string) = []string{"q3"}
+		}).
+		Return(nil)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, "DELETE FROM task_tags WHERE task_id = ?", []interface{}{int64(1)}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{"blocked", "q3"}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), "blocked", "q3"}).
+		Return(&mockResult{rowsAffected: 2}, nil).Once()
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.ActionUpdate, args[2])
+			assert.Equal(t, model.Changes{
+				"tags": {From: model.TagList{"q3"}, To: model.TagList{"blocked", "q3"}},
+			}, args[4])
+		}).
+		Return(&mockResult{lastInsertID: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{}, nil).Once()
+
+	affected, err := model.UpdateTask(context.Background(), mockDB, 1, 1, &req, model.WriteOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// keysetFields are the sort fields a cursor can be built from. They must be
+// NOT NULL and compare in SQL the same way they sort, which rules out the
+// nullable dates and the status ENUM.
+var keysetFields = map[string]bool{
+	"id":         true,
+	"title":      true,
+	"priority":   true,
+	"created_at": true,
+	"updated_at": true,
+}
+
+// Cursor is the decoded form of the opaque next_cursor/prev_cursor tokens.
+// It carries the sort it was issued for and the value of every sort key
+// (primary key last) of the row it points at.
+type Cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+func DecodeCursor(token string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cur Cursor
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cur, nil
+}
+
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// check makes sure the cursor was issued for this ordering; reusing a token
+// after changing sort would silently skip or repeat rows.
+func (c *Cursor) check(keys []SortKey) error {
+	if c.Sort != sortString(keys) || len(c.Values) != len(keys) {
+		return ErrInvalidCursor
+	}
+	for i, k := range keys {
+		if _, err := parseSortValue(k.Field, c.Values[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// keyset expands the cursor into a predicate that selects the rows strictly
+// after it in the (possibly reversed) ordering:
+//
+//	(k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... OR (k1 = v1 AND ... AND id > vid)
+//
+// Each comparison is a plain column/value pair, so an index whose columns
+// follow the sort keys, e.g. (priority, created_at, id), can drive the scan.
+func (c *Cursor) keyset(keys []SortKey) (string, []interface{}) {
+	values := make([]interface{}, len(keys))
+	for i, k := range keys {
+		values[i], _ = parseSortValue(k.Field, c.Values[i])
+	}
+
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i, k := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, taskSortColumns[keys[j].Field]+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if k.Desc != c.Backward {
+			op = "<"
+		}
+		ands = append(ands, taskSortColumns[k.Field]+" "+op+" ?")
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+func keysetCapable(keys []SortKey) bool {
+	for _, k := range keys {
+		if !keysetFields[k.Field] {
+			return false
+		}
+	}
+	return true
+}
+
+func newCursor(keys []SortKey, task *Task, backward bool) string {
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = sortValue(task, k.Field)
+	}
+	cur := Cursor{Sort: sortString(keys), Values: values, Backward: backward}
+	return cur.Encode()
+}
+
+func sortString(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k.Field
+		if k.Desc {
+			parts[i] = "-" + k.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func sortValue(task *Task, field string) string {
+	switch field {
+	case "id":
+		return strconv.FormatInt(task.ID, 10)
+	case "title":
+		return task.Title
+	case "priority":
+		return strconv.Itoa(int(task.Priority))
+	case "created_at":
+		return task.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return task.UpdatedAt.Format(time.RFC3339Nano)
+	}
+	return ""
+}
+
+func parseSortValue(field, raw string) (interface{}, error) {
+	switch field {
+	case "id", "priority":
+		return strconv.ParseInt(raw, 10, 64)
+	case "title":
+		return raw, nil
+	case "created_at", "updated_at":
+		return time.Parse(time.RFC3339Nano, raw)
+	}
+	return nil, ErrInvalidCursor
+}
@@ -0,0 +1,205 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrDependencyCycle = errors.New("dependency would make a task wait on itself")
+
+// BlockedError refuses to start or finish a task while some of its blockers
+// are not done. WriteOptions.Force overrides it.
+type BlockedError struct {
+	TaskID    int64
+	Status    TaskStatus
+	BlockedBy []int64
+}
+
+func (e *BlockedError) Error() string {
+	ids := make([]string, len(e.BlockedBy))
+	for i, id := range e.BlockedBy {
+		ids[i] = fmt.Sprint(id)
+	}
+	return fmt.Sprintf("cannot move task %d to %s while it is blocked by unfinished tasks %s", e.TaskID, e.Status, strings.Join(ids, ", "))
+}
+
+// TaskDependency is an edge of the dependency graph: BlockerID blocks
+// BlockedID.
+type TaskDependency struct {
+	BlockerID int64 `db:"blocker_id"`
+	BlockedID int64 `db:"blocked_id"`
+}
+
+// DependencyTask is the other end of a dependency.
+type DependencyTask struct {
+	ID     int64      `json:"id" db:"id"`
+	Title  string     `json:"title" db:"title"`
+	Status TaskStatus `json:"status" db:"status"`
+}
+
+// TaskDependencies lists the tasks a task waits on and the tasks waiting on
+// it. Tasks in the trash are left out.
+type TaskDependencies struct {
+	BlockedBy []DependencyTask `json:"blocked_by"`
+	Blocks    []DependencyTask `json:"blocks"`
+}
+
+const (
+	queryGetBlockers = `
+		SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.blocked_id = ? AND t.deleted_at IS NULL
+		ORDER BY t.id
+	`
+
+	queryGetBlocked = `
+		SELECT t.id, t.title, t.status
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.blocked_id
+		WHERE d.blocker_id = ? AND t.deleted_at IS NULL
+		ORDER BY t.id
+	`
+
+	queryUnfinishedBlockers = `
+		SELECT t.id
+		FROM task_dependencies d
+		INNER JOIN tasks t ON t.id = d.blocker_id
+		WHERE d.blocked_id = ? AND t.status <> 'done' AND t.deleted_at IS NULL
+		ORDER BY t.id
+	`
+
+	// queryLockBlocked is completed with placeholders for the blockers
+	// whose edges are read.
+	queryLockBlocked = `
+		SELECT blocked_id FROM task_dependencies
+		WHERE blocker_id IN (%s)
+		FOR UPDATE
+	`
+
+	queryAddDependency = `
+	INSERT INTO task_dependencies (blocker_id, blocked_id) VALUES (?, ?)
+	ON DUPLICATE KEY UPDATE created_at = created_at
+	`
+
+	queryRemoveDependency = `
+	DELETE d FROM task_dependencies d
+	INNER JOIN tasks t ON t.id = d.blocked_id
+	WHERE d.blocked_id = ? AND d.blocker_id = ? AND t.project_id = ?
+	`
+)
+
+// GetTaskDependencies returns both sides of a task's dependencies, or
+// sql.ErrNoRows when the project has no such live task.
+func GetTaskDependencies(ctx context.Context, db DBTX, projectID, taskID int64) (*TaskDependencies, error) {
+	if _, err := GetByID(ctx, db, projectID, taskID); err != nil {
+		return nil, err
+	}
+
+	deps := &TaskDependencies{BlockedBy: []DependencyTask{}, Blocks: []DependencyTask{}}
+	if err := db.SelectContext(ctx, &deps.BlockedBy, queryGetBlockers, taskID); err != nil {
+		return nil, err
+	}
+	if err := db.SelectContext(ctx, &deps.Blocks, queryGetBlocked, taskID); err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// AddTaskDependency records that blockerID blocks taskID. Both must be live
+// tasks of the project, or sql.ErrNoRows is returned, and the new edge must
+// not close a loop, which gives ErrDependencyCycle. Adding an edge that
+// exists already changes nothing.
+func AddTaskDependency(ctx context.Context, db DBTX, projectID, taskID, blockerID int64) error {
+	if taskID == blockerID {
+		return ErrDependencyCycle
+	}
+
+	return WithTx(ctx, db, func(tx DBTX) error {
+		// Two edges added at the same time could each pass the check below
+		// and close a loop together, and not only between the same two
+		// tasks, so the whole project is locked before anything is read.
+		if err := lockProject(ctx, tx, projectID); err != nil {
+			return err
+		}
+
+		for _, id := range []int64{taskID, blockerID} {
+			if _, err := lockTask(ctx, tx, projectID, id); err != nil {
+				return err
+			}
+		}
+
+		cycle, err := blocks(ctx, tx, taskID, blockerID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrDependencyCycle
+		}
+
+		_, err = tx.ExecContext(ctx, queryAddDependency, blockerID, taskID)
+		return err
+	})
+}
+
+// blocks reports whether task from blocks task to, directly or through
+// other tasks. The edges are read a level at a time with locking reads: on
+// TiDB a plain read answers from the snapshot the transaction started with,
+// and would miss an edge committed by the previous holder of the project
+// lock. A task already reached is not followed again, so the walk ends even
+// on a shared subgraph.
+func blocks(ctx context.Context, tx DBTX, from, to int64) (bool, error) {
+	seen := map[int64]bool{from: true}
+	level := []int64{from}
+	for len(level) > 0 {
+		args := make([]interface{}, len(level))
+		for i, id := range level {
+			args[i] = id
+		}
+		var blocked []int64
+		if err := tx.SelectContext(ctx, &blocked, fmt.Sprintf(queryLockBlocked, placeholders(len(args))), args...); err != nil {
+			return false, err
+		}
+
+		level = nil
+		for _, id := range blocked {
+			if id == to {
+				return true, nil
+			}
+			if !seen[id] {
+				seen[id] = true
+				level = append(level, id)
+			}
+		}
+	}
+	return false, nil
+}
+
+// RemoveTaskDependency drops the edge between blockerID and taskID. It
+// returns 0 when there was none in the project.
+func RemoveTaskDependency(ctx context.Context, db DBTX, projectID, taskID, blockerID int64) (int64, error) {
+	res, err := db.ExecContext(ctx, queryRemoveDependency, taskID, blockerID, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// checkBlockers refuses a status change that starts or finishes a task while
+// it still waits on unfinished tasks.
+func checkBlockers(ctx context.Context, db DBTX, task *Task, status TaskStatus, opts WriteOptions) error {
+	if opts.Force || status == task.Status || (status != StatusInProgress && status != StatusDone) {
+		return nil
+	}
+
+	var blockers []int64
+	if err := db.SelectContext(ctx, &blockers, queryUnfinishedBlockers, task.ID); err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return &BlockedError{TaskID: task.ID, Status: status, BlockedBy: blockers}
+	}
+	return nil
+}
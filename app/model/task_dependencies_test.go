@@ -0,0 +1,137 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+// expectLiveTasks answers the lookup of each of the tasks of project 1.
+func expectLiveTasks(mockDB *model.MockDBTX, ids ...int64) {
+	for _, id := range ids {
+		id := id
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{id, int64(1)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*model.Task) = model.Task{ID: id, ProjectID: 1}
+			}).
+			Return(nil).Once()
+	}
+}
+
+// expectBlocked answers the locking read of the tasks blocked by blockers.
+func expectBlocked(mockDB *model.MockDBTX, blockers []interface{}, blocked ...int64) {
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, "FROM task_dependencies") && strings.HasSuffix(strings.TrimSpace(query), "FOR UPDATE")
+		}), blockers).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]int64) = blocked
+		}).
+		Return(nil).Once()
+}
+
+func TestAddTaskDependency(t *testing.T) {
+	t.Run("self", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		err := model.AddTaskDependency(context.Background(), mockDB, 1, 4, 4)
+		assert.Equal(t, model.ErrDependencyCycle, err)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectProjectLock(mockDB)
+		expectLiveTasks(mockDB, 4, 5)
+		// Task 4 already blocks task 5 through tasks 6 and 7, so 5 cannot
+		// block 4. Task 6 is reached twice but followed once.
+		expectBlocked(mockDB, []interface{}{int64(4)}, 6, 7)
+		expectBlocked(mockDB, []interface{}{int64(6), int64(7)}, 6, 5)
+
+		err := model.AddTaskDependency(context.Background(), mockDB, 1, 4, 5)
+		assert.Equal(t, model.ErrDependencyCycle, err)
+	})
+
+	t.Run("other project", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectProjectLock(mockDB)
+		expectLiveTasks(mockDB, 4)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(7), int64(1)}).
+			Return(sql.ErrNoRows).Once()
+
+		err := model.AddTaskDependency(context.Background(), mockDB, 1, 4, 7)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+
+	t.Run("added", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectProjectLock(mockDB)
+		expectLiveTasks(mockDB, 4, 5)
+		expectBlocked(mockDB, []interface{}{int64(4)}, 6)
+		expectBlocked(mockDB, []interface{}{int64(6)}, 4)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, []interface{}{int64(5), int64(4)}).
+			Return(&mockResult{rowsAffected: 1}, nil)
+
+		assert.NoError(t, model.AddTaskDependency(context.Background(), mockDB, 1, 4, 5))
+	})
+}
+
+func TestUpdateTask_Blocked(t *testing.T) {
+	status := model.StatusInProgress
+	req := &model.UpdateTaskRequest{Status: &status}
+
+	expectTodo := func(mockDB *model.MockDBTX) {
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*model.Task) = model.Task{ID: 4, Status: model.StatusTodo}
+			}).
+			Return(nil).Once()
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectTodo(mockDB)
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*[]int64) = []int64{2, 5}
+			}).
+			Return(nil)
+
+		_, err := model.UpdateTask(context.Background(), mockDB, 1, 4, req, model.WriteOptions{})
+
+		var blocked *model.BlockedError
+		assert.True(t, errors.As(err, &blocked))
+		assert.Equal(t, []int64{2, 5}, blocked.BlockedBy)
+		assert.Equal(t, "cannot move task 4 to in_progress while it is blocked by unfinished tasks 2, 5", err.Error())
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectTodo(mockDB)
+		mockDB.EXPECT().
+			NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+			Return(&mockResult{rowsAffected: 1}, nil)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*model.Task) = model.Task{ID: 4, Status: model.StatusInProgress}
+			}).
+			Return(nil).Once()
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, mock.Anything).
+			Return(&mockResult{lastInsertID: 1}, nil)
+
+		affected, err := model.UpdateTask(context.Background(), mockDB, 1, 4, req, model.WriteOptions{Force: true})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+	})
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+type TaskAction string
+
+const (
+	ActionCreate  TaskAction = "create"
+	ActionUpdate  TaskAction = "update"
+	ActionMove    TaskAction = "move"
+	ActionDelete  TaskAction = "delete"
+	ActionRestore TaskAction = "restore"
+)
+
+// FieldChange is the before and after value of one task field.
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Changes maps task fields, by their JSON name, to how they changed. It is
+// stored as a JSON column.
+type Changes map[string]FieldChange
+
+func (c *Changes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return fmt.Errorf("cannot scan %T into Changes", value)
+}
+
+func (c Changes) Value() (driver.Value, error) {
+	raw, err := json.Marshal(c)
+	return string(raw), err
+}
+
+// TaskEvent is one entry in a task's history.
+type TaskEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	TaskID    int64           `json:"task_id" db:"task_id"`
+	Action    TaskAction      `json:"action" db:"action"`
+	Actor     null.NullString `json:"actor" db:"actor"`
+	Changes   Changes         `json:"changes" db:"changes"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// HistoryFilter narrows GET /tasks/:id/history.
+type HistoryFilter struct {
+	Field string
+	Since null.NullTime
+	Until null.NullTime
+}
+
+// untrackedFields change on every write and would only add noise.
+var untrackedFields = map[string]bool{
+	"updated_at": true,
+}
+
+// extraTrackedFields are recorded in the history but are not columns of the
+// tasks row.
+var extraTrackedFields = map[string]bool{
+	"tags": true,
+}
+
+const (
+	queryInsertTaskEvent = `
+	INSERT INTO task_events (project_id, task_id, action, actor, changes)
+	VALUES (?, ?, ?, ?, ?)
+	`
+
+	queryGetTaskEvents = `
+		SELECT id, task_id, action, actor, changes, created_at
+		FROM task_events
+		WHERE project_id = ? AND task_id = ?
+	`
+)
+
+// IsTrackedField reports whether history can be filtered by field.
+func IsTrackedField(field string) bool {
+	if extraTrackedFields[field] {
+		return true
+	}
+	snapshot, _ := taskSnapshot(&Task{})
+	_, ok := snapshot[field]
+	return ok && !untrackedFields[field]
+}
+
+// DiffTasks compares two snapshots of a task field by field. A nil before
+// describes a task being created.
+func DiffTasks(before, after *Task) (Changes, error) {
+	from, err := taskSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := taskSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := Changes{}
+	for field, value := range to {
+		if untrackedFields[field] {
+			continue
+		}
+		if !reflect.DeepEqual(from[field], value) {
+			changes[field] = FieldChange{From: from[field], To: value}
+		}
+	}
+	return changes, nil
+}
+
+func taskSnapshot(task *Task) (map[string]interface{}, error) {
+	snapshot := map[string]interface{}{}
+	if task == nil {
+		return snapshot, nil
+	}
+
+	raw, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &snapshot)
+	return snapshot, err
+}
+
+// recordTaskEvent writes a history entry. It is meant to run on the same
+// transaction as the change it describes.
+func recordTaskEvent(ctx context.Context, db DBTX, projectID, taskID int64, action TaskAction, actor string, changes Changes) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	var who null.NullString
+	if actor != "" {
+		who = null.NullStringOf(actor)
+	}
+	_, err := db.ExecContext(ctx, queryInsertTaskEvent, projectID, taskID, action, who, changes)
+	return err
+}
+
+// GetTaskHistory returns the events of a task of the project, oldest first.
+func GetTaskHistory(ctx context.Context, db DBTX, projectID, taskID int64, filter *HistoryFilter) ([]TaskEvent, error) {
+	query := queryGetTaskEvents
+	args := []interface{}{projectID, taskID}
+
+	if filter.Field != "" {
+		query += " AND JSON_CONTAINS_PATH(changes, 'one', ?)"
+		args = append(args, "$."+filter.Field)
+	}
+	if filter.Since.Valid() {
+		query += " AND created_at >= ?"
+		args = append(args, filter.Since.TimeValue())
+	}
+	if filter.Until.Valid() {
+		query += " AND created_at <= ?"
+		args = append(args, filter.Until.TimeValue())
+	}
+	query += " ORDER BY created_at ASC, id ASC"
+
+	var events []TaskEvent
+	err := db.SelectContext(ctx, &events, query, args...)
+	return events, err
+}
@@ -0,0 +1,76 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestDiffTasks(t *testing.T) {
+	before := &model.Task{ID: 1, Title: "Task", Status: model.StatusTodo, Priority: 1, UpdatedAt: time.Now()}
+	after := *before
+	after.Status = model.StatusDone
+	after.DueDate = nulltype.NullTimeOf(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	after.UpdatedAt = before.UpdatedAt.Add(time.Minute)
+
+	changes, err := model.DiffTasks(before, &after)
+	assert.NoError(t, err)
+
+	// updated_at is not tracked
+	assert.Equal(t, model.Changes{
+		"status":   {From: "todo", To: "done"},
+		"due_date": {From: nil, To: "2025-08-01T00:00:00Z"},
+	}, changes)
+
+	// Nothing changed
+	changes, err = model.DiffTasks(before, before)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(changes))
+}
+
+func TestIsTrackedField(t *testing.T) {
+	assert.True(t, model.IsTrackedField("status"))
+	assert.True(t, model.IsTrackedField("parent_task_id"))
+	assert.False(t, model.IsTrackedField("updated_at"))
+	assert.False(t, model.IsTrackedField("password"))
+}
+
+func TestGetTaskHistory_Filter(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	since := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1), "$.status", since}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "JSON_CONTAINS_PATH(changes, 'one', ?)"))
+			assert.True(t, strings.Contains(query, "created_at >= ?"))
+			assert.False(t, strings.Contains(query, "created_at <= ?"))
+			*dest.(*[]model.TaskEvent) = []model.TaskEvent{
+				{ID: 1, TaskID: 1, Action: model.ActionUpdate, Changes: model.Changes{"status": {From: "todo", To: "done"}}},
+			}
+		}).
+		Return(nil)
+
+	events, err := model.GetTaskHistory(context.Background(), mockDB, 1, 1, &model.HistoryFilter{
+		Field: "status",
+		Since: nulltype.NullTimeOf(since),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "done", events[0].Changes["status"].To)
+}
+
+func TestChanges_Scan(t *testing.T) {
+	var changes model.Changes
+	assert.NoError(t, changes.Scan([]byte(`{"title":{"from":"a","to":"b"}}`)))
+	assert.Equal(t, model.Changes{"title": {From: "a", To: "b"}}, changes)
+
+	assert.Error(t, changes.Scan(42))
+}
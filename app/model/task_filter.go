@@ -0,0 +1,223 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+const (
+	DefaultTaskLimit = 50
+	MaxTaskLimit     = 200
+)
+
+// taskSortColumns whitelists the fields GET /tasks can be ordered by.
+var taskSortColumns = map[string]string{
+	"id":           "t.id",
+	"title":        "t.title",
+	"status":       "t.status",
+	"priority":     "t.priority",
+	"due_date":     "t.due_date",
+	"completed_at": "t.completed_at",
+	"created_at":   "t.created_at",
+	"updated_at":   "t.updated_at",
+}
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// TaskFilter narrows, orders and pages a task listing.
+type TaskFilter struct {
+	// ProjectID is the project the listing is confined to. It is always
+	// applied; no listing spans projects.
+	ProjectID  int64
+	Statuses   []TaskStatus
+	Categories []string
+	// Subcategories widens Categories to everything below them.
+	Subcategories bool
+	// TagsAny, TagsAll and TagsNone keep tasks carrying at least one, every
+	// one, or none of their tags.
+	TagsAny      []string
+	TagsAll      []string
+	TagsNone     []string
+	PriorityMin  null.NullInt64
+	PriorityMax  null.NullInt64
+	DueAfter     null.NullTime
+	DueBefore    null.NullTime
+	ParentTaskID null.NullInt64
+	RootOnly     bool
+	// AssigneeID keeps the tasks assigned to one user, Unassigned those
+	// assigned to nobody.
+	AssigneeID null.NullInt64
+	Unassigned bool
+	Completed  *bool
+	Sort       []SortKey
+	Limit      int
+	Offset     int
+	Cursor     *Cursor
+}
+
+// ParseSort reads a comma separated list of fields, each optionally
+// prefixed with "-" for descending order, e.g. "-priority,created_at".
+func ParseSort(raw string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key := SortKey{Field: part}
+		if strings.HasPrefix(part, "-") {
+			key = SortKey{Field: part[1:], Desc: true}
+		}
+		if _, ok := taskSortColumns[key.Field]; !ok {
+			return nil, fmt.Errorf("cannot sort by %q", key.Field)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Validate checks the parts of a filter that depend on each other.
+func (f *TaskFilter) Validate() error {
+	if f.Cursor == nil {
+		return nil
+	}
+	if f.Offset != 0 {
+		return errors.New("offset cannot be combined with cursor")
+	}
+	keys := f.sortKeys()
+	for _, k := range keys {
+		if !keysetFields[k.Field] {
+			return fmt.Errorf("cursor pagination cannot sort by %q", k.Field)
+		}
+	}
+	return f.Cursor.check(keys)
+}
+
+func (f *TaskFilter) where() (string, []interface{}) {
+	conds, args := f.conditions()
+	return whereClause(conds), args
+}
+
+func (f *TaskFilter) conditions() ([]string, []interface{}) {
+	var (
+		conds = []string{"t.project_id = ?", "t.deleted_at IS NULL"}
+		args  = []interface{}{f.ProjectID}
+	)
+
+	if len(f.Statuses) > 0 {
+		conds = append(conds, "t.status IN ("+placeholders(len(f.Statuses))+")")
+		for _, s := range f.Statuses {
+			args = append(args, s)
+		}
+	}
+	if len(f.Categories) > 0 {
+		if f.Subcategories {
+			conds = append(conds, "t.category_id IN ("+fmt.Sprintf(queryCategoryTree, placeholders(len(f.Categories)))+")")
+		} else {
+			conds = append(conds, "c.path IN ("+placeholders(len(f.Categories))+")")
+		}
+		for _, name := range f.Categories {
+			args = append(args, name)
+		}
+		if f.Subcategories {
+			args = append(args, f.ProjectID)
+		}
+	}
+	conds, args = f.tagConditions(conds, args)
+	if f.PriorityMin.Valid() {
+		conds = append(conds, "t.priority >= ?")
+		args = append(args, f.PriorityMin.Int64Value())
+	}
+	if f.PriorityMax.Valid() {
+		conds = append(conds, "t.priority <= ?")
+		args = append(args, f.PriorityMax.Int64Value())
+	}
+	if f.DueAfter.Valid() {
+		conds = append(conds, "t.due_date >= ?")
+		args = append(args, f.DueAfter.TimeValue())
+	}
+	if f.DueBefore.Valid() {
+		conds = append(conds, "t.due_date <= ?")
+		args = append(args, f.DueBefore.TimeValue())
+	}
+	if f.RootOnly {
+		conds = append(conds, "t.parent_task_id IS NULL")
+	} else if f.ParentTaskID.Valid() {
+		conds = append(conds, "t.parent_task_id = ?")
+		args = append(args, f.ParentTaskID.Int64Value())
+	}
+	if f.Unassigned {
+		conds = append(conds, "t.assignee_id IS NULL")
+	} else if f.AssigneeID.Valid() {
+		conds = append(conds, "t.assignee_id = ?")
+		args = append(args, f.AssigneeID.Int64Value())
+	}
+	if f.Completed != nil {
+		if *f.Completed {
+			conds = append(conds, "t.completed_at IS NOT NULL")
+		} else {
+			conds = append(conds, "t.completed_at IS NULL")
+		}
+	}
+
+	return conds, args
+}
+
+// sortKeys is the effective ordering, always ending with the primary key so
+// pages are stable.
+func (f *TaskFilter) sortKeys() []SortKey {
+	keys := f.Sort
+	if len(keys) == 0 {
+		keys = []SortKey{{Field: "priority", Desc: true}, {Field: "created_at"}}
+	}
+	for _, k := range keys {
+		if k.Field == "id" {
+			return keys
+		}
+	}
+	return append(keys[:len(keys):len(keys)], SortKey{Field: "id"})
+}
+
+// orderBy flips every direction when reverse is set, which is how a
+// backward cursor walks towards the start of the listing.
+func (f *TaskFilter) orderBy(reverse bool) string {
+	keys := f.sortKeys()
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		dir := "ASC"
+		if k.Desc != reverse {
+			dir = "DESC"
+		}
+		parts = append(parts, taskSortColumns[k.Field]+" "+dir)
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// PageSize is the number of rows a page holds once Limit is clamped.
+func (f *TaskFilter) PageSize() int {
+	switch {
+	case f.Limit <= 0:
+		return DefaultTaskLimit
+	case f.Limit > MaxTaskLimit:
+		return MaxTaskLimit
+	}
+	return f.Limit
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conds, " AND ")
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
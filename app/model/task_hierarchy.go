@@ -0,0 +1,120 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// maxHierarchyScan bounds the walks below so that a tree which is already
+// broken cannot make them run away.
+const maxHierarchyScan = 1000
+
+var (
+	ErrParentNotFound   = errors.New("parent task not found")
+	ErrTaskCycle        = errors.New("task cannot be its own ancestor")
+	ErrMaxDepthExceeded = errors.New("task hierarchy is too deep")
+)
+
+// ParentError explains why a task cannot be attached to a parent. It wraps
+// one of ErrParentNotFound, ErrTaskCycle or ErrMaxDepthExceeded.
+type ParentError struct {
+	TaskID   int64
+	ParentID int64
+	Err      error
+}
+
+func (e *ParentError) Error() string {
+	if e.TaskID == 0 {
+		return fmt.Sprintf("cannot use task %d as parent: %v", e.ParentID, e.Err)
+	}
+	return fmt.Sprintf("cannot move task %d under task %d: %v", e.TaskID, e.ParentID, e.Err)
+}
+
+func (e *ParentError) Unwrap() error {
+	return e.Err
+}
+
+// Both walks lock what they read. Besides keeping the rows in place until the
+// write, a locking read sees the latest committed rows, where TiDB would
+// answer a plain read from the snapshot the transaction started with.
+const (
+	queryLockParent = `
+		SELECT parent_task_id FROM tasks
+		WHERE id = ? AND project_id = ? AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	queryLockChildren = `
+		SELECT id FROM tasks
+		WHERE project_id = ? AND parent_task_id IN (%s) AND deleted_at IS NULL
+		FOR UPDATE
+	`
+)
+
+// ValidateParent checks that taskID can be placed under parentID: the parent
+// must exist outside the trash in the same project, which keeps every tree
+// inside one project, must not be the task itself or one of its
+// descendants, and the resulting tree must fit in maxDepth levels (0 means
+// unlimited). Pass a taskID of 0 for a task that does not exist yet.
+//
+// The check only holds until someone else changes the tree, so callers take
+// lockProject first and keep it until the write.
+func ValidateParent(ctx context.Context, db DBTX, projectID, taskID, parentID int64, maxDepth int) error {
+	if taskID == parentID {
+		return &ParentError{TaskID: taskID, ParentID: parentID, Err: ErrTaskCycle}
+	}
+
+	// The parent and its ancestors, nearest first.
+	var ancestors []int64
+	for id := parentID; len(ancestors) < maxHierarchyScan; {
+		var parent null.NullInt64
+		err := db.GetContext(ctx, &parent, queryLockParent, id, projectID)
+		if err == sql.ErrNoRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if id == taskID {
+			return &ParentError{TaskID: taskID, ParentID: parentID, Err: ErrTaskCycle}
+		}
+		ancestors = append(ancestors, id)
+		if !parent.Valid() {
+			break
+		}
+		id = parent.Int64Value()
+	}
+	if len(ancestors) == 0 {
+		return &ParentError{TaskID: taskID, ParentID: parentID, Err: ErrParentNotFound}
+	}
+
+	if maxDepth <= 0 {
+		return nil
+	}
+
+	// The task and its subtree, a level at a time, for as long as they fit.
+	// A new task is a single level.
+	level := []int64{taskID}
+	for depth := len(ancestors) + 1; len(level) > 0; depth++ {
+		if depth > maxDepth {
+			return &ParentError{TaskID: taskID, ParentID: parentID, Err: ErrMaxDepthExceeded}
+		}
+		if taskID == 0 {
+			break
+		}
+
+		args := []interface{}{projectID}
+		for _, id := range level {
+			args = append(args, id)
+		}
+		level = nil
+		if err := db.SelectContext(ctx, &level, fmt.Sprintf(queryLockChildren, placeholders(len(args)-1)), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
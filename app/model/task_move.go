@@ -0,0 +1,163 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+var ErrInvalidPosition = errors.New("position must not be negative")
+
+// queryNextPosition appends a task after its last sibling; top-level tasks
+// are only siblings within a project. The siblings are read through a
+// derived table because MySQL does not allow a subquery on the table being
+// written to.
+const queryNextPosition = `(
+	SELECT COALESCE(MAX(s.position) + 1, 0)
+	FROM (
+		SELECT position FROM tasks
+		WHERE project_id = :project_id AND parent_task_id <=> :parent_task_id AND deleted_at IS NULL
+	) s
+)`
+
+const querySiblingIDs = `
+	SELECT id FROM tasks
+	WHERE project_id = ? AND parent_task_id <=> ? AND id <> ? AND deleted_at IS NULL
+	ORDER BY position ASC, priority DESC, created_at ASC, id ASC
+	FOR UPDATE
+`
+
+// MoveTaskRequest places a task, with its whole subtree, under a new parent
+// at a given index among the parent's children.
+type MoveTaskRequest struct {
+	// ParentTaskID is the new parent; null moves the task to the top level.
+	ParentTaskID null.NullInt64 `json:"parent_task_id"`
+	// Position is the zero based index among the new siblings. Left out, the
+	// task goes last; past the end, it is clamped.
+	Position *int `json:"position"`
+
+	// KeepParent is set when parent_task_id was left out of the request, which
+	// only reorders the task among its current siblings.
+	KeepParent bool `json:"-"`
+}
+
+func (r *MoveTaskRequest) UnmarshalJSON(data []byte) error {
+	type plain MoveTaskRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	_, hasParent := raw["parent_task_id"]
+	r.KeepParent = !hasParent
+	return nil
+}
+
+// MoveTask re-parents a task and renumbers the siblings it leaves and joins,
+// all in one transaction. Descendants follow their ancestor through
+// parent_task_id, so the whole subtree moves with it.
+//
+// The project is locked before anything is read, so a concurrent move or
+// re-parenting PATCH cannot change the tree under the checks, and the
+// renumbering of two moves cannot interleave.
+func MoveTask(ctx context.Context, db DBTX, projectID, taskID int64, req *MoveTaskRequest, opts WriteOptions) (*Task, error) {
+	if req.Position != nil && *req.Position < 0 {
+		return nil, ErrInvalidPosition
+	}
+
+	var moved *Task
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		if err := lockProject(ctx, tx, projectID); err != nil {
+			return err
+		}
+		task, err := lockTask(ctx, tx, projectID, taskID)
+		if err != nil {
+			return err
+		}
+
+		oldParent := task.ParentTaskID
+		newParent := req.ParentTaskID
+		if req.KeepParent {
+			newParent = oldParent
+		}
+		if newParent.Valid() {
+			if err := ValidateParent(ctx, tx, projectID, taskID, newParent.Int64Value(), opts.MaxDepth); err != nil {
+				return err
+			}
+		}
+
+		var siblings []int64
+		if err := tx.SelectContext(ctx, &siblings, querySiblingIDs, projectID, newParent, taskID); err != nil {
+			return err
+		}
+
+		pos := len(siblings)
+		if req.Position != nil && *req.Position < pos {
+			pos = *req.Position
+		}
+		siblings = append(siblings[:pos], append([]int64{taskID}, siblings[pos:]...)...)
+
+		if _, err := tx.ExecContext(ctx, "UPDATE tasks SET parent_task_id = ? WHERE id = ?", newParent, taskID); err != nil {
+			return err
+		}
+		if err := renumber(ctx, tx, siblings); err != nil {
+			return err
+		}
+
+		// Close the gap left among the old siblings.
+		if oldParent != newParent {
+			var previous []int64
+			if err := tx.SelectContext(ctx, &previous, querySiblingIDs, projectID, oldParent, taskID); err != nil {
+				return err
+			}
+			if err := renumber(ctx, tx, previous); err != nil {
+				return err
+			}
+		}
+
+		if moved, err = GetByID(ctx, tx, projectID, taskID); err != nil {
+			return err
+		}
+		changes, err := DiffTasks(task, moved)
+		if err != nil {
+			return err
+		}
+		if err := recordTaskEvent(ctx, tx, projectID, taskID, ActionMove, opts.Actor, changes); err != nil {
+			return err
+		}
+		return recordOutboxEvent(ctx, tx, projectID, EventTaskMoved, taskID, moved, changes, opts.Actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
+
+// renumber stores ids' slice order as their position, in a single statement.
+func renumber(ctx context.Context, db DBTX, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var (
+		cases strings.Builder
+		args  []interface{}
+	)
+	for i, id := range ids {
+		cases.WriteString(" WHEN ? THEN ?")
+		args = append(args, id, i)
+	}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := "UPDATE tasks SET position = CASE id" + cases.String() + " END WHERE id IN (" + placeholders(len(ids)) + ")"
+	_, err := db.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNoFieldsToUpdate = errors.New("no fields to update")
+
+// ErrCompletedAtCleared refuses a null completed_at: a done task always has
+// one, and leaving done is what clears it.
+var ErrCompletedAtCleared = errors.New("completed_at cannot be null, it is cleared by moving the task out of done")
+
+// notNullFields are the merge patch keys whose columns cannot be cleared.
+var notNullFields = []string{"title", "status", "priority", "recur_subtasks"}
+
+func (r *UpdateTaskRequest) UnmarshalJSON(data []byte) error {
+	type plain UpdateTaskRequest
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	r.Cleared = make(map[string]bool)
+	for key, value := range raw {
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			r.Cleared[key] = true
+		}
+	}
+	return nil
+}
+
+// Validate rejects nulls for columns that must always hold a value.
+func (r *UpdateTaskRequest) Validate() error {
+	for _, key := range notNullFields {
+		if r.Cleared[key] {
+			return fmt.Errorf("%s cannot be null", key)
+		}
+	}
+	if r.Status != nil && !r.Status.IsValid() {
+		return ErrInvalidStatus
+	}
+	if r.Cleared["completed_at"] && (r.Status == nil || *r.Status == StatusDone) {
+		return ErrCompletedAtCleared
+	}
+	if !ValidEstimate(r.EstimateHours) {
+		return ErrInvalidEstimate
+	}
+	if r.Recurrence.Valid() {
+		if _, err := ParseRecurrence(r.Recurrence.StringValue()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *UpdateTaskRequest) IsEmpty() bool {
+	sets, _ := r.assignments()
+	return len(sets) == 0 && !r.replacesTags()
+}
+
+// replacesTags reports whether the patch sets or clears the tags, which live
+// outside the tasks row.
+func (r *UpdateTaskRequest) replacesTags() bool {
+	return r.Tags != nil || r.Cleared["tags"]
+}
+
+// assignments builds the SET list from only the fields present in the patch.
+func (r *UpdateTaskRequest) assignments() ([]string, map[string]interface{}) {
+	var sets []string
+	args := map[string]interface{}{}
+
+	set := func(key, expr string, value interface{}, present bool) {
+		if present || r.Cleared[key] {
+			sets = append(sets, expr)
+			args[key] = value
+		}
+	}
+
+	set("title", "title = :title", r.Title, r.Title.Valid())
+	set("description", "description = :description", r.Description, r.Description.Valid())
+
+	// completed_at is assigned before status so that it still sees the old
+	// row: entering done stamps it (unless the client supplied one), leaving
+	// done clears it, and it can only be edited while the task is done.
+	if r.Status != nil || r.CompletedAt.Valid() {
+		sets = append(sets, `completed_at = CASE
+			WHEN COALESCE(:status, status) = 'done' THEN COALESCE(:completed_at, completed_at, NOW())
+			ELSE NULL
+		END`)
+		args["status"] = r.Status
+		args["completed_at"] = r.CompletedAt
+	}
+	set("status", "status = :status", r.Status, r.Status != nil)
+
+	set("priority", "priority = :priority", r.Priority, r.Priority.Valid())
+	set("due_date", "due_date = :due_date", r.DueDate, r.DueDate.Valid())
+	set("estimate_hours", "estimate_hours = :estimate_hours", r.EstimateHours, r.EstimateHours.Valid())
+	set("recurrence", "recurrence = :recurrence", r.Recurrence, r.Recurrence.Valid())
+	set("recur_subtasks", "recur_subtasks = :recur_subtasks", r.RecurSubtasks, r.RecurSubtasks != nil)
+	// A task that changes parent goes to the end of its new siblings.
+	if r.ParentTaskID.Valid() || r.Cleared["parent_task_id"] {
+		sets = append(sets, "position = "+queryNextPosition)
+	}
+	set("parent_task_id", "parent_task_id = :parent_task_id", r.ParentTaskID, r.ParentTaskID.Valid())
+	set("category_name", "category_id = "+queryCategoryID, r.CategoryName, r.CategoryName.Valid())
+	set("assignee_id", "assignee_id = :assignee_id", r.AssigneeID, r.AssigneeID.Valid())
+	set("reporter_id", "reporter_id = :reporter_id", r.ReporterID, r.ReporterID.Valid())
+
+	return sets, args
+}
@@ -1,7 +1,12 @@
 package model_test
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,14 +31,15 @@ func TestGetByID_Success(t *testing.T) {
 	}
 
 	mockDB.EXPECT().
-		Get(mock.Anything, mock.Anything, []interface{}{int64(1)}).
-		Run(func(dest interface{}, query string, args ...interface{}) {
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "project_id = ?"))
 			d := dest.(*model.Task)
 			*d = *expected
 		}).
 		Return(nil)
 
-	got, err := model.GetByID(mockDB, 1)
+	got, err := model.GetByID(context.Background(), mockDB, 1, 1)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, got)
@@ -43,10 +49,10 @@ func TestGetByID_NotFound(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
 	mockDB.EXPECT().
-		Get(mock.Anything, mock.Anything, []interface{}{int64(999)}).
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(999), int64(1)}).
 		Return(sql.ErrNoRows)
 
-	got, err := model.GetByID(mockDB, 999)
+	got, err := model.GetByID(context.Background(), mockDB, 1, 999)
 	assert.Error(t, err)
 	assert.Nil(t, got)
 	assert.Equal(t, sql.ErrNoRows, err)
@@ -83,14 +89,15 @@ func TestGetAllTasks_Success(t *testing.T) {
 	}
 
 	mockDB.EXPECT().
-		Select(mock.Anything, mock.Anything).
-		Run(func(dest interface{}, query string, args ...interface{}) {
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), model.DefaultTaskLimit, 0}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "WHERE t.project_id = ? AND t.deleted_at IS NULL"))
 			d := dest.(*[]model.TaskWithCategory)
 			*d = expected
 		}).
 		Return(nil)
 
-	tasks, err := model.GetAllTasks(mockDB)
+	tasks, err := model.GetAllTasks(context.Background(), mockDB, &model.TaskFilter{ProjectID: 1})
 
 	assert.NoError(t, err)
 	assert.Equal(t, expected, tasks)
@@ -100,16 +107,176 @@ func TestGetAllTasks_DBError(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
 	mockDB.EXPECT().
-		Select(mock.Anything, mock.Anything).
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(sql.ErrConnDone)
 
-	tasks, err := model.GetAllTasks(mockDB)
+	tasks, err := model.GetAllTasks(context.Background(), mockDB, &model.TaskFilter{})
 
 	assert.Error(t, err)
 	assert.Nil(t, tasks)
 	assert.Equal(t, sql.ErrConnDone, err)
 }
 
+func TestGetAllTasks_Filtered(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	completed := false
+	filter := &model.TaskFilter{
+		ProjectID:   1,
+		Statuses:    []model.TaskStatus{model.StatusTodo, model.StatusInProgress},
+		Categories:  []string{"Backend"},
+		PriorityMin: nulltype.NullInt64Of(2),
+		RootOnly:    true,
+		Completed:   &completed,
+		Sort:        []model.SortKey{{Field: "due_date", Desc: true}},
+		Limit:       500,
+		Offset:      20,
+	}
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{
+			int64(1), model.StatusTodo, model.StatusInProgress, "Backend", int64(2), model.MaxTaskLimit, 20,
+		}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "t.status IN (?, ?)"))
+			assert.True(t, strings.Contains(query, "c.path IN (?)"))
+			assert.True(t, strings.Contains(query, "t.parent_task_id IS NULL"))
+			assert.True(t, strings.Contains(query, "t.completed_at IS NULL"))
+			assert.True(t, strings.Contains(query, "ORDER BY t.due_date DESC, t.id ASC"))
+		}).
+		Return(nil)
+
+	_, err := model.GetAllTasks(context.Background(), mockDB, filter)
+	assert.NoError(t, err)
+}
+
+func TestGetAllTasks_Subcategories(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	filter := &model.TaskFilter{ProjectID: 1, Categories: []string{"Backend", "Bug"}, Subcategories: true}
+
+	// The category tree is walked from the project's own categories only.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), "Backend", "Bug", int64(1), model.DefaultTaskLimit, 0}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "t.category_id IN ("))
+			assert.True(t, strings.Contains(query, "WHERE path IN (?, ?) AND project_id = ?"))
+			assert.True(t, strings.Contains(query, "INNER JOIN category_tree ct ON c.parent_id = ct.id"))
+		}).
+		Return(nil)
+
+	_, err := model.GetAllTasks(context.Background(), mockDB, filter)
+	assert.NoError(t, err)
+}
+
+func TestCountTasks_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	filter := &model.TaskFilter{ProjectID: 2, ParentTaskID: nulltype.NullInt64Of(1), Limit: 10}
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(2), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "t.parent_task_id = ?"))
+			assert.False(t, strings.Contains(query, "LIMIT"))
+			*dest.(*int64) = 42
+		}).
+		Return(nil)
+
+	total, err := model.CountTasks(context.Background(), mockDB, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), total)
+}
+
+func TestParseSort(t *testing.T) {
+	keys, err := model.ParseSort("-priority, created_at")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.SortKey{{Field: "priority", Desc: true}, {Field: "created_at"}}, keys)
+
+	_, err = model.ParseSort("description")
+	assert.Error(t, err)
+}
+
+func TestGetTaskPage_Cursors(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	created := time.Date(2025, 8, 1, 10, 0, 0, 0, time.UTC)
+	rows := []model.TaskWithCategory{
+		{Task: model.Task{ID: 7, Priority: 3, CreatedAt: created}},
+		{Task: model.Task{ID: 4, Priority: 2, CreatedAt: created}},
+		{Task: model.Task{ID: 9, Priority: 2, CreatedAt: created.Add(time.Hour)}},
+	}
+
+	// Two rows per page, so the handler asks for three.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), 3, 0}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]model.TaskWithCategory) = rows
+		}).
+		Return(nil)
+
+	page, err := model.GetTaskPage(context.Background(), mockDB, &model.TaskFilter{ProjectID: 1, Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(page.Tasks))
+	assert.Equal(t, "", page.PrevCursor)
+
+	next, err := model.DecodeCursor(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, "-priority,created_at,id", next.Sort)
+	assert.Equal(t, []string{"2", created.Format(time.RFC3339Nano), "4"}, next.Values)
+
+	// Following the cursor issues a keyset query instead of an offset.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{
+			int64(1),
+			int64(2),
+			int64(2), created,
+			int64(2), created, int64(4),
+			3, 0,
+		}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query,
+				"((t.priority < ?) OR (t.priority = ? AND t.created_at > ?) OR (t.priority = ? AND t.created_at = ? AND t.id > ?))"))
+			*dest.(*[]model.TaskWithCategory) = rows[2:]
+		}).
+		Return(nil)
+
+	filter := &model.TaskFilter{ProjectID: 1, Limit: 2, Cursor: next}
+	assert.NoError(t, filter.Validate())
+
+	page, err = model.GetTaskPage(context.Background(), mockDB, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.Tasks))
+	assert.Equal(t, "", page.NextCursor)
+
+	prev, err := model.DecodeCursor(page.PrevCursor)
+	assert.NoError(t, err)
+	assert.True(t, prev.Backward)
+}
+
+func TestTaskFilterValidate_Cursor(t *testing.T) {
+	cur := &model.Cursor{Sort: "-priority,created_at,id", Values: []string{"2", "2025-08-01T10:00:00Z", "4"}}
+
+	assert.NoError(t, (&model.TaskFilter{Cursor: cur}).Validate())
+
+	// Issued for a different ordering.
+	assert.Equal(t, model.ErrInvalidCursor, (&model.TaskFilter{
+		Cursor: cur,
+		Sort:   []model.SortKey{{Field: "title"}},
+	}).Validate())
+
+	// Nullable columns cannot seed a keyset.
+	assert.Error(t, (&model.TaskFilter{
+		Cursor: cur,
+		Sort:   []model.SortKey{{Field: "due_date"}},
+	}).Validate())
+
+	assert.Error(t, (&model.TaskFilter{Cursor: cur, Offset: 10}).Validate())
+}
+
 // mockResult implements sql.Result for testing
 type mockResult struct {
 	lastInsertID int64
@@ -137,15 +304,19 @@ func TestCreateTask_Success(t *testing.T) {
 		CategoryName: nulltype.NullStringOf("Work"),
 	}
 
-	// Step 1: Stub NamedExec to return fake result
+	// Step 1: The category name is resolved, then the insert runs
+	expectCategories(mockDB, "Work")
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, arg interface{}) {
+			assert.Equal(t, int64(1), arg.(map[string]interface{})["project_id"])
+		}).
 		Return(&mockResult{lastInsertID: 1}, nil)
 
 	// Step 2: Stub GetByID call (CreateTask calls GetByID after insert)
 	mockDB.EXPECT().
-		Get(mock.Anything, mock.Anything, []interface{}{int64(1)}).
-		Run(func(dest interface{}, query string, args ...interface{}) {
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
 			task := dest.(*model.Task)
 			task.ID = 1
 			task.Title = req.Title
@@ -155,7 +326,28 @@ func TestCreateTask_Success(t *testing.T) {
 		}).
 		Return(nil)
 
-	task, err := model.CreateTask(mockDB, req)
+	// Step 3: The creation is written to the task history
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, []interface{}{int64(1), int64(1)}, args[:2])
+			assert.Equal(t, model.ActionCreate, args[2])
+			assert.Equal(t, nulltype.NullStringOf("alice"), args[3])
+			changes := args[4].(model.Changes)
+			assert.Equal(t, "New Task", changes["title"].To)
+			assert.Nil(t, changes["title"].From)
+		}).
+		Return(&mockResult{lastInsertID: 1}, nil).Once()
+
+	// Step 4: The event is put in the outbox
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, []interface{}{int64(1), int64(1), model.EventTaskCreated}, args[:3])
+		}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+
+	task, err := model.CreateTask(context.Background(), mockDB, 1, req, model.WriteOptions{Actor: "alice"})
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), task.ID)
@@ -176,10 +368,11 @@ func TestCreateTask_DBError(t *testing.T) {
 		CategoryName: nulltype.NullStringOf("Work"),
 	}
 
+	expectCategories(mockDB, "Work")
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
 		Return(nil, sql.ErrConnDone)
-	task, err := model.CreateTask(mockDB, req)
+	task, err := model.CreateTask(context.Background(), mockDB, 1, req, model.WriteOptions{})
 
 	assert.Error(t, err)
 	assert.Nil(t, task)
@@ -189,22 +382,57 @@ func TestCreateTask_DBError(t *testing.T) {
 func TestUpdateTask_Success(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
-	status, _ := model.StatusInProgress.Value()
+	status := model.StatusInProgress
 	completedAt := nulltype.NullTimeOf(time.Now())
 	req := &model.UpdateTaskRequest{
 		Title:       nulltype.NullStringOf("Updated Task"),
 		Description: nulltype.NullStringOf("This task has been updated"),
-		Status:      nulltype.NullStringOf(status.(string)),
+		Status:      &status,
 		Priority:    nulltype.NullInt64Of(3),
 		DueDate:     nulltype.NullTimeOf(time.Now().Add(72 * time.Hour)),
 		CompletedAt: completedAt,
 	}
 
+	// The task is read before and after the update to record the diff
+	current := model.Task{ID: 1, Title: "Task", Status: model.StatusTodo}
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = current
+		}).
+		Return(nil)
+
+	// Starting the task first checks that nothing blocks it
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Return(nil)
+
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, arg interface{}) {
+			current.Title = "Updated Task"
+			current.Status = model.StatusInProgress
+		}).
 		Return(&mockResult{rowsAffected: 1}, nil)
 
-	rowsAffected, err := model.UpdateTask(mockDB, 1, req)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.ActionUpdate, args[2])
+			assert.Equal(t, model.Changes{
+				"title":  {From: "Task", To: "Updated Task"},
+				"status": {From: "todo", To: "in_progress"},
+			}, args[4])
+		}).
+		Return(&mockResult{lastInsertID: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.EventTaskUpdated, args[2])
+		}).
+		Return(&mockResult{}, nil).Once()
+
+	rowsAffected, err := model.UpdateTask(context.Background(), mockDB, 1, 1, req, model.WriteOptions{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffected)
@@ -213,33 +441,100 @@ func TestUpdateTask_Success(t *testing.T) {
 func TestUpdateTask_DBError(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
-	status, _ := model.StatusInProgress.Value()
+	status := model.StatusInProgress
 	req := &model.UpdateTaskRequest{
 		Title:       nulltype.NullStringOf("Updated Task"),
 		Description: nulltype.NullStringOf("This task has been updated"),
-		Status:      nulltype.NullStringOf(status.(string)),
+		Status:      &status,
 		Priority:    nulltype.NullInt64Of(3),
 		DueDate:     nulltype.NullTimeOf(time.Now().Add(72 * time.Hour)),
 	}
 
 	mockDB.EXPECT().
-		NamedExec(mock.Anything, mock.Anything).
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 1, Status: model.StatusTodo}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
 		Return(nil, sql.ErrConnDone)
 
-	rowsAffected, err := model.UpdateTask(mockDB, 1, req)
+	rowsAffected, err := model.UpdateTask(context.Background(), mockDB, 1, 1, req, model.WriteOptions{})
 
 	assert.Error(t, err)
 	assert.Equal(t, int64(0), rowsAffected)
 	assert.Equal(t, sql.ErrConnDone, err)
 }
 
+func TestUpdateTask_Unchanged(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The title is already the one sent, so MySQL reports no affected rows
+	// and there is nothing to record.
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 1, Title: "Task", Status: model.StatusTodo}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Return(&mockResult{rowsAffected: 0}, nil)
+
+	rowsAffected, err := model.UpdateTask(context.Background(), mockDB, 1, 1, &model.UpdateTaskRequest{Title: nulltype.NullStringOf("Task")}, model.WriteOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+}
+
+func TestUpdateTask_IllegalTransition(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// The status is checked against the locked row, and nothing is written
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.HasSuffix(query, "FOR UPDATE")
+		}), []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 1, Status: model.StatusTodo}
+		}).
+		Return(nil)
+
+	status := model.StatusDone
+	_, err := model.UpdateTask(context.Background(), mockDB, 1, 1, &model.UpdateTaskRequest{Status: &status}, model.WriteOptions{})
+
+	assert.Equal(t, &model.TransitionError{From: model.StatusTodo, To: model.StatusDone}, err)
+}
+
 func TestDeleteTask_Success(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
+	// Soft delete with the task as the batch, then read back deleted_at for
+	// the history entry
 	mockDB.EXPECT().
-		Exec(mock.Anything, mock.Anything).
+		ExecContext(mock.Anything, mock.Anything, []interface{}{uint64(1), int64(1), uint64(1)}).
 		Return(&mockResult{rowsAffected: 1}, nil)
-	rowsAffected, err := model.DeleteTask(mockDB, 1)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{uint64(1)}).
+		Return(nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.ActionDelete, args[2])
+		}).
+		Return(&mockResult{rowsAffected: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.EventTaskDeleted, args[2])
+		}).
+		Return(&mockResult{}, nil).Once()
+	rowsAffected, err := model.DeleteTask(context.Background(), mockDB, 1, 1, model.WriteOptions{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(1), rowsAffected)
@@ -249,11 +544,411 @@ func TestDeleteTask_DBError(t *testing.T) {
 	mockDB := model.NewMockDBTX(t)
 
 	mockDB.EXPECT().
-		Exec(mock.Anything, mock.Anything).
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
 		Return(nil, sql.ErrConnDone)
-	rowsAffected, err := model.DeleteTask(mockDB, 1)
+	rowsAffected, err := model.DeleteTask(context.Background(), mockDB, 1, 1, model.WriteOptions{})
 
 	assert.Error(t, err)
 	assert.Equal(t, int64(0), rowsAffected)
 	assert.Equal(t, sql.ErrConnDone, err)
 }
+
+func TestValidateTransition(t *testing.T) {
+	cases := []struct {
+		from, to model.TaskStatus
+		ok       bool
+	}{
+		{model.StatusTodo, model.StatusInProgress, true},
+		{model.StatusTodo, model.StatusDone, false},
+		{model.StatusInProgress, model.StatusDone, true},
+		{model.StatusInProgress, model.StatusTodo, true},
+		{model.StatusDone, model.StatusInProgress, true},
+		{model.StatusDone, model.StatusTodo, true},
+		{model.StatusDone, model.StatusDone, true},
+	}
+
+	for _, tc := range cases {
+		err := model.ValidateTransition(tc.from, tc.to)
+		if tc.ok {
+			assert.NoError(t, err)
+		} else {
+			assert.Equal(t, &model.TransitionError{From: tc.from, To: tc.to}, err)
+		}
+	}
+
+	assert.Equal(t, model.ErrInvalidStatus, model.ValidateTransition(model.StatusTodo, "blocked"))
+}
+
+func TestUpdateTaskRequest_MergePatch(t *testing.T) {
+	var req model.UpdateTaskRequest
+	err := json.Unmarshal([]byte(`{"title":"Renamed","due_date":null,"parent_task_id":null}`), &req)
+	assert.NoError(t, err)
+	assert.NoError(t, req.Validate())
+
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil)
+	mockDB.EXPECT().
+		NamedExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, arg interface{}) {
+			assert.True(t, strings.Contains(query, "title = :title"))
+			assert.True(t, strings.Contains(query, "due_date = :due_date"))
+			assert.True(t, strings.Contains(query, "parent_task_id = :parent_task_id"))
+			assert.False(t, strings.Contains(query, "description"))
+			assert.False(t, strings.Contains(query, "category_id"))
+
+			args := arg.(map[string]interface{})
+			dueDate := args["due_date"].(nulltype.NullTime)
+			parentID := args["parent_task_id"].(nulltype.NullInt64)
+			assert.False(t, dueDate.Valid())
+			assert.False(t, parentID.Valid())
+		}).
+		Return(&mockResult{rowsAffected: 1}, nil)
+
+	rowsAffected, err := model.UpdateTask(context.Background(), mockDB, 1, 1, &req, model.WriteOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), rowsAffected)
+}
+
+func TestUpdateTaskRequest_NullNotAllowed(t *testing.T) {
+	var req model.UpdateTaskRequest
+	err := json.Unmarshal([]byte(`{"title":null}`), &req)
+	assert.NoError(t, err)
+	assert.Error(t, req.Validate())
+}
+
+func TestUpdateTaskRequest_ClearCompletedAt(t *testing.T) {
+	for _, tc := range []struct {
+		body string
+		err  error
+	}{
+		{`{"completed_at":null}`, model.ErrCompletedAtCleared},
+		{`{"status":"done","completed_at":null}`, model.ErrCompletedAtCleared},
+		{`{"status":"in_progress","completed_at":null}`, nil},
+	} {
+		t.Run(tc.body, func(t *testing.T) {
+			var req model.UpdateTaskRequest
+			assert.NoError(t, json.Unmarshal([]byte(tc.body), &req))
+			assert.Equal(t, tc.err, req.Validate())
+		})
+	}
+}
+
+func TestUpdateTask_NoFields(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	rowsAffected, err := model.UpdateTask(context.Background(), mockDB, 1, 1, &model.UpdateTaskRequest{}, model.WriteOptions{})
+
+	assert.Equal(t, model.ErrNoFieldsToUpdate, err)
+	assert.Equal(t, int64(0), rowsAffected)
+}
+
+// expectParent answers the locked read of a task's parent in project 1, 0
+// standing for a top-level task.
+func expectParent(mockDB *model.MockDBTX, id, parent int64) {
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, "FOR UPDATE")
+		}), []interface{}{id, int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			if parent != 0 {
+				*dest.(*nulltype.NullInt64) = nulltype.NullInt64Of(parent)
+			}
+		}).
+		Return(nil).Once()
+}
+
+func TestValidateParent(t *testing.T) {
+	// Task 5 sits under 3, which sits under 1.
+	expectAncestorsOf3 := func(mockDB *model.MockDBTX) {
+		expectParent(mockDB, 3, 1)
+		expectParent(mockDB, 1, 0)
+	}
+
+	t.Run("self", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		err := model.ValidateParent(context.Background(), mockDB, 1, 5, 5, 0)
+		assert.True(t, errors.Is(err, model.ErrTaskCycle))
+	})
+
+	t.Run("descendant", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectAncestorsOf3(mockDB)
+
+		err := model.ValidateParent(context.Background(), mockDB, 1, 1, 3, 0)
+		assert.True(t, errors.Is(err, model.ErrTaskCycle))
+	})
+
+	t.Run("missing parent", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(42), int64(1)}).
+			Return(sql.ErrNoRows)
+
+		err := model.ValidateParent(context.Background(), mockDB, 1, 5, 42, 0)
+		assert.True(t, errors.Is(err, model.ErrParentNotFound))
+	})
+
+	t.Run("too deep", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectAncestorsOf3(mockDB)
+		// Task 7 has children of its own
+		mockDB.EXPECT().
+			SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(7)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				assert.True(t, strings.HasSuffix(strings.TrimSpace(query), "FOR UPDATE"))
+				*dest.(*[]int64) = []int64{8}
+			}).
+			Return(nil)
+
+		err := model.ValidateParent(context.Background(), mockDB, 1, 7, 3, 3)
+		assert.True(t, errors.Is(err, model.ErrMaxDepthExceeded))
+	})
+
+	t.Run("ok", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		expectAncestorsOf3(mockDB)
+
+		assert.NoError(t, model.ValidateParent(context.Background(), mockDB, 1, 0, 3, 3))
+	})
+}
+
+// projectTree is the task tree of project 1, shared by the transactions of a
+// test. lock stands in for the row lock on the project, which a transaction
+// holds until it ends.
+type projectTree struct {
+	lock    sync.Mutex
+	mu      sync.Mutex
+	parents map[int64]int64
+}
+
+// treeTx is a transaction on a projectTree that answers the statements of
+// re-parenting a task with UpdateTask or MoveTask.
+type treeTx struct {
+	tree   *projectTree
+	locked bool
+	// pause runs between the check of the new parent and its write.
+	pause func()
+}
+
+func (tx *treeTx) commit() {
+	if tx.locked {
+		tx.tree.lock.Unlock()
+	}
+}
+
+func (tx *treeTx) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	if strings.Contains(query, "FROM projects") {
+		tx.tree.lock.Lock()
+		tx.locked = true
+		return nil
+	}
+
+	id := args[0].(int64)
+	tx.tree.mu.Lock()
+	parent, ok := tx.tree.parents[id]
+	tx.tree.mu.Unlock()
+	if !ok {
+		return sql.ErrNoRows
+	}
+	var parentID nulltype.NullInt64
+	if parent != 0 {
+		parentID = nulltype.NullInt64Of(parent)
+	}
+	switch dest := dest.(type) {
+	case *nulltype.NullInt64:
+		*dest = parentID
+	case *model.Task:
+		*dest = model.Task{ID: id, ProjectID: 1, Status: model.StatusTodo, ParentTaskID: parentID}
+	}
+	return nil
+}
+
+// SelectContext finds no siblings, so positions are left out of the test.
+func (tx *treeTx) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return nil
+}
+
+func (tx *treeTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if strings.Contains(query, "SET parent_task_id") {
+		tx.setParent(args[1].(int64), args[0].(nulltype.NullInt64))
+	}
+	return &mockResult{rowsAffected: 1}, nil
+}
+
+func (tx *treeTx) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	args := arg.(map[string]interface{})
+	tx.setParent(int64(args["id"].(uint64)), args["parent_task_id"].(nulltype.NullInt64))
+	return &mockResult{rowsAffected: 1}, nil
+}
+
+func (tx *treeTx) setParent(id int64, parent nulltype.NullInt64) {
+	if tx.pause != nil {
+		tx.pause()
+	}
+	tx.tree.mu.Lock()
+	defer tx.tree.mu.Unlock()
+	tx.tree.parents[id] = parent.Int64Value()
+}
+
+// assertOneReparentWins moves top-level tasks 1 and 2 under each other at the
+// same time with reparent. Both checks would pass on the tree as it was, so
+// only one of the moves may go through.
+func assertOneReparentWins(t *testing.T, reparent func(tx model.DBTX, taskID, parentID int64) error) {
+	tree := &projectTree{parents: map[int64]int64{1: 0, 2: 0}}
+	moves := [][2]int64{{1, 2}, {2, 1}}
+
+	start := make(chan struct{})
+	errs := make([]error, len(moves))
+	var wg sync.WaitGroup
+	for i, move := range moves {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx := &treeTx{tree: tree, pause: func() { time.Sleep(20 * time.Millisecond) }}
+			defer tx.commit()
+
+			<-start
+			errs[i] = reparent(tx, move[0], move[1])
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			assert.True(t, errors.Is(err, model.ErrTaskCycle))
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+	assert.True(t, tree.parents[1] == 0 || tree.parents[2] == 0)
+}
+
+func TestUpdateTask_ConcurrentReparent(t *testing.T) {
+	assertOneReparentWins(t, func(tx model.DBTX, taskID, parentID int64) error {
+		req := &model.UpdateTaskRequest{ParentTaskID: nulltype.NullInt64Of(parentID)}
+		_, err := model.UpdateTask(context.Background(), tx, 1, uint64(taskID), req, model.WriteOptions{})
+		return err
+	})
+}
+
+func TestMoveTask_ConcurrentReparent(t *testing.T) {
+	assertOneReparentWins(t, func(tx model.DBTX, taskID, parentID int64) error {
+		_, err := model.MoveTask(context.Background(), tx, 1, taskID, &model.MoveTaskRequest{ParentTaskID: nulltype.NullInt64Of(parentID)}, model.WriteOptions{})
+		return err
+	})
+}
+
+func TestGetTaskWithSubtasks_NestedOrder(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Rows arrive in sibling order; the grandchild comes before its parent's
+	// sibling, which used to get lost when copying into the tree.
+	flat := []model.TaskHierarchy{
+		{Task: model.Task{ID: 1, Title: "Root"}},
+		{Task: model.Task{ID: 3, Title: "First", ParentTaskID: nulltype.NullInt64Of(1), Position: 0}},
+		{Task: model.Task{ID: 4, Title: "Grandchild", ParentTaskID: nulltype.NullInt64Of(3), Position: 0}},
+		{Task: model.Task{ID: 2, Title: "Second", ParentTaskID: nulltype.NullInt64Of(1), Position: 1}},
+	}
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]model.TaskHierarchy) = flat
+		}).
+		Return(nil)
+
+	root, err := model.GetTaskWithSubtasks(context.Background(), mockDB, 1, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(root.Subtasks))
+	assert.Equal(t, "First", root.Subtasks[0].Title)
+	assert.Equal(t, "Second", root.Subtasks[1].Title)
+	assert.Equal(t, 1, len(root.Subtasks[0].Subtasks))
+	assert.Equal(t, "Grandchild", root.Subtasks[0].Subtasks[0].Title)
+}
+
+func TestMoveTask_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Task 5 moves from the top level to position 1 under task 2. The
+	// project is locked first.
+	task := model.Task{ID: 5, Title: "Moving"}
+	expectProjectLock(mockDB)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(5), int64(1)}).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*model.Task) = task
+			return nil
+		})
+
+	// The new parent is top-level. Then come its current children and the
+	// siblings left behind at the top level.
+	expectParent(mockDB, 2, 0)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), nulltype.NullInt64Of(2), int64(5)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]int64) = []int64{7, 8}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), nulltype.NullInt64{}, int64(5)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]int64) = []int64{1}
+		}).
+		Return(nil)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{nulltype.NullInt64Of(2), int64(5)}).
+		RunAndReturn(func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			task.ParentTaskID = nulltype.NullInt64Of(2)
+			return &mockResult{rowsAffected: 1}, nil
+		})
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(7), 0, int64(5), 1, int64(8), 2, int64(7), int64(5), int64(8)}).
+		RunAndReturn(func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			task.Position = 1
+			return &mockResult{rowsAffected: 3}, nil
+		})
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), 0, int64(1)}).
+		Return(&mockResult{rowsAffected: 1}, nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.ActionMove, args[2])
+			changes := args[4].(model.Changes)
+			assert.Equal(t, 2, len(changes))
+			assert.Equal(t, float64(2), changes["parent_task_id"].To)
+		}).
+		Return(&mockResult{lastInsertID: 1}, nil).Once()
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.EventTaskMoved, args[2])
+		}).
+		Return(&mockResult{}, nil).Once()
+
+	position := 1
+	moved, err := model.MoveTask(context.Background(), mockDB, 1, 5, &model.MoveTaskRequest{
+		ParentTaskID: nulltype.NullInt64Of(2),
+		Position:     &position,
+	}, model.WriteOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), moved.ParentTaskID.Int64Value())
+	assert.Equal(t, 1, moved.Position)
+}
+
+func TestMoveTask_NegativePosition(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	position := -1
+	_, err := model.MoveTask(context.Background(), mockDB, 1, 5, &model.MoveTaskRequest{Position: &position}, model.WriteOptions{})
+
+	assert.Equal(t, model.ErrInvalidPosition, err)
+}
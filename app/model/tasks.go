@@ -1,9 +1,13 @@
 package model
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	null "github.com/mattn/go-nulltype"
@@ -30,62 +34,187 @@ func (s *TaskStatus) Scan(value interface{}) error {
 	return fmt.Errorf("cannot scan %T into TaskStatus", value)
 }
 
+func (s TaskStatus) IsValid() bool {
+	switch s {
+	case StatusTodo, StatusInProgress, StatusDone:
+		return true
+	}
+	return false
+}
+
 func (s TaskStatus) Value() (driver.Value, error) {
 	return string(s), nil
 }
 
+// statusTransitions lists the statuses a task may move to from each status.
+// Reopening a finished task is allowed; skipping straight from todo to done
+// is not. Staying in the same status is always allowed. They only apply to
+// updates: a new task may start in any status, so that finished work can be
+// recorded, and starting as done stamps completed_at.
+var statusTransitions = map[TaskStatus][]TaskStatus{
+	StatusTodo:       {StatusInProgress},
+	StatusInProgress: {StatusTodo, StatusDone},
+	StatusDone:       {StatusInProgress, StatusTodo},
+}
+
+var ErrInvalidStatus = errors.New("invalid status")
+
+// maxEstimateHours matches the width of tasks.estimate_hours.
+const maxEstimateHours = 99999
+
+var ErrInvalidEstimate = fmt.Errorf("estimate_hours must be between 0 and %d", maxEstimateHours)
+
+// ValidEstimate reports whether estimate can be stored; null always can.
+func ValidEstimate(estimate null.NullFloat64) bool {
+	if !estimate.Valid() {
+		return true
+	}
+	hours := estimate.Float64Value()
+	return hours >= 0 && hours <= maxEstimateHours
+}
+
+// TransitionError reports a status change the state machine does not allow.
+type TransitionError struct {
+	From TaskStatus
+	To   TaskStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move task from %s to %s", e.From, e.To)
+}
+
+func ValidateTransition(from, to TaskStatus) error {
+	if !to.IsValid() {
+		return ErrInvalidStatus
+	}
+	if from == to {
+		return nil
+	}
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
 type Task struct {
-	ID           int64           `json:"id" db:"id"`
-	Title        string          `json:"title" db:"title"`
-	Description  null.NullString `json:"description" db:"description"`
-	Status       TaskStatus      `json:"status" db:"status"`
-	Priority     int8            `json:"priority" db:"priority"`
-	DueDate      null.NullTime   `json:"due_date" db:"due_date"`
-	CompletedAt  null.NullTime   `json:"completed_at" db:"completed_at"`
-	ParentTaskID null.NullInt64  `json:"parent_task_id" db:"parent_task_id"`
-	CategoryID   null.NullInt64  `json:"category_id" db:"category_id"`
-	CreatedAt    time.Time       `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time       `json:"updated_at" db:"updated_at"`
+	ID          int64           `json:"id" db:"id"`
+	ProjectID   int64           `json:"project_id" db:"project_id"`
+	Title       string          `json:"title" db:"title"`
+	Description null.NullString `json:"description" db:"description"`
+	Status      TaskStatus      `json:"status" db:"status"`
+	Priority    int8            `json:"priority" db:"priority"`
+	DueDate     null.NullTime   `json:"due_date" db:"due_date"`
+	// EstimateHours is how much work is left on the task, which the
+	// schedule uses as its duration.
+	EstimateHours null.NullFloat64 `json:"estimate_hours" db:"estimate_hours"`
+	// Recurrence is an RRULE; finishing the task creates the next
+	// occurrence, along with copies of the subtasks when RecurSubtasks is
+	// set.
+	Recurrence    null.NullString `json:"recurrence" db:"recurrence"`
+	RecurSubtasks bool            `json:"recur_subtasks" db:"recur_subtasks"`
+	CompletedAt   null.NullTime   `json:"completed_at" db:"completed_at"`
+	ParentTaskID  null.NullInt64  `json:"parent_task_id" db:"parent_task_id"`
+	Position      int             `json:"position" db:"position"`
+	CategoryID    null.NullInt64  `json:"category_id" db:"category_id"`
+	AssigneeID    null.NullInt64  `json:"assignee_id" db:"assignee_id"`
+	ReporterID    null.NullInt64  `json:"reporter_id" db:"reporter_id"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+	DeletedAt     null.NullTime   `json:"deleted_at" db:"deleted_at"`
+	// DeletedRootID is the task whose delete put this one in the trash.
+	DeletedRootID null.NullInt64 `json:"-" db:"deleted_root_id"`
 }
 
 type TaskWithCategory struct {
 	Task
 	CategoryName *string `json:"category_name" db:"category_name"`
+	Tags         TagList `json:"tags" db:"tags"`
+}
+
+type TaskPage struct {
+	Tasks      []TaskWithCategory
+	NextCursor string
+	PrevCursor string
 }
 
 type TaskHierarchy struct {
 	Task
 	CategoryName *string         `json:"category_name" db:"category_name"`
+	Tags         TagList         `json:"tags" db:"tags"`
 	Subtasks     []TaskHierarchy `json:"subtasks,omitempty"`
+	// Schedule is only filled in on the tasks of a Schedule.
+	Schedule *TaskSchedule `json:"schedule,omitempty" db:"-"`
 }
 
 type CreateTaskRequest struct {
-	Title        string          `json:"title"`
-	Description  null.NullString `json:"description"`
-	Status       *TaskStatus     `json:"status"`
-	Priority     int8            `json:"priority"`
-	DueDate      null.NullTime   `json:"due_date"`
-	ParentTaskID null.NullInt64  `json:"parent_task_id"`
-	CategoryName null.NullString `json:"category_name"`
+	Title         string           `json:"title"`
+	Description   null.NullString  `json:"description"`
+	Status        *TaskStatus      `json:"status"`
+	Priority      int8             `json:"priority"`
+	DueDate       null.NullTime    `json:"due_date"`
+	EstimateHours null.NullFloat64 `json:"estimate_hours"`
+	Recurrence    null.NullString  `json:"recurrence"`
+	RecurSubtasks bool             `json:"recur_subtasks"`
+	ParentTaskID  null.NullInt64   `json:"parent_task_id"`
+	CategoryName  null.NullString  `json:"category_name"`
+	AssigneeID    null.NullInt64   `json:"assignee_id"`
+	ReporterID    null.NullInt64   `json:"reporter_id"`
+	Tags          []string         `json:"tags"`
+}
+
+// WriteOptions carries the settings that shape how task mutations are
+// validated.
+type WriteOptions struct {
+	MaxDepth int
+	// CreateCategory creates an unknown category_name instead of rejecting
+	// it with an *UnknownCategoryError.
+	CreateCategory bool
+	// Actor names who is making the change, for the task history.
+	Actor string
+	// Force starts or finishes a task even though it is blocked by
+	// unfinished tasks.
+	Force bool
 }
 
+// UpdateTaskRequest is a JSON Merge Patch (RFC 7396) of a task: keys that
+// are absent leave the column untouched, keys set to null clear it.
 type UpdateTaskRequest struct {
-	Title        null.NullString `db:"title"`
-	Description  null.NullString `db:"description"`
-	Status       null.NullString `db:"status"`
-	Priority     null.NullInt64  `db:"priority"`
-	DueDate      null.NullTime   `db:"due_date"`
-	CompletedAt  null.NullTime   `db:"completed_at"`
-	ParentTaskID null.NullInt64  `db:"parent_task_id"`
-	CategoryName null.NullString `db:"category_name"`
+	Title         null.NullString  `json:"title" db:"title"`
+	Description   null.NullString  `json:"description" db:"description"`
+	Status        *TaskStatus      `json:"status" db:"status"`
+	Priority      null.NullInt64   `json:"priority" db:"priority"`
+	DueDate       null.NullTime    `json:"due_date" db:"due_date"`
+	EstimateHours null.NullFloat64 `json:"estimate_hours" db:"estimate_hours"`
+	Recurrence    null.NullString  `json:"recurrence" db:"recurrence"`
+	RecurSubtasks *bool            `json:"recur_subtasks" db:"recur_subtasks"`
+	CompletedAt   null.NullTime    `json:"completed_at" db:"completed_at"`
+	ParentTaskID  null.NullInt64   `json:"parent_task_id" db:"parent_task_id"`
+	CategoryName  null.NullString  `json:"category_name" db:"category_name"`
+	AssigneeID    null.NullInt64   `json:"assignee_id" db:"assignee_id"`
+	ReporterID    null.NullInt64   `json:"reporter_id" db:"reporter_id"`
+	// Tags replaces the task's tags when present; null or [] removes them.
+	Tags []string `json:"tags" db:"-"`
+
+	// Cleared holds the keys that were explicitly set to null.
+	Cleared map[string]bool `json:"-" db:"-"`
 }
 
 const (
 	queryAllGetTasks = `
 		SELECT 
-			t.id, t.title, t.description, t.status, t.priority, 
-			t.due_date, t.completed_at, t.parent_task_id, t.category_id,
-			t.created_at, t.updated_at, c.name as category_name
+			t.id, t.project_id, t.title, t.description, t.status, t.priority, 
+			t.due_date, t.estimate_hours, t.recurrence, t.recur_subtasks, t.completed_at, t.parent_task_id, t.position, t.category_id,
+			t.assignee_id, t.reporter_id,
+			t.created_at, t.updated_at, t.deleted_at, c.path as category_name,
+			` + queryTagsOf + `t.id) AS tags
+		FROM tasks t
+		LEFT JOIN categories c ON t.category_id = c.id
+	`
+
+	queryCountTasks = `
+		SELECT COUNT(*)
 		FROM tasks t
 		LEFT JOIN categories c ON t.category_id = c.id
 	`
@@ -93,167 +222,438 @@ const (
 	queryGetTaskHierarchy = `
 	WITH RECURSIVE task_hierarchy AS (
 		SELECT 
-			id, title, description, status, priority, 
-			due_date, completed_at, parent_task_id, category_id,
-			created_at, updated_at
+			id, project_id, title, description, status, priority, 
+			due_date, estimate_hours, recurrence, recur_subtasks, completed_at, parent_task_id, position, category_id,
+			assignee_id, reporter_id,
+			created_at, updated_at, deleted_at
 		FROM tasks
-		WHERE id = ?
+		WHERE id = ? AND project_id = ? AND deleted_at IS NULL
 
 		UNION ALL
 
 		SELECT 
-			t.id, t.title, t.description, t.status, t.priority, 
-			t.due_date, t.completed_at, t.parent_task_id, t.category_id,
-			t.created_at, t.updated_at
+			t.id, t.project_id, t.title, t.description, t.status, t.priority, 
+			t.due_date, t.estimate_hours, t.recurrence, t.recur_subtasks, t.completed_at, t.parent_task_id, t.position, t.category_id,
+			t.assignee_id, t.reporter_id,
+			t.created_at, t.updated_at, t.deleted_at
 		FROM tasks t
-		INNER JOIN task_hierarchy th ON t.parent_task_id = th.id
+		INNER JOIN task_hierarchy th ON t.parent_task_id = th.id AND t.project_id = th.project_id
+		WHERE t.deleted_at IS NULL
 	)
 	SELECT 
-		th.*, c.name as category_name
+		th.*, c.path as category_name,
+		` + queryTagsOf + `th.id) AS tags
 	FROM task_hierarchy th
 	LEFT JOIN categories c ON th.category_id = c.id
-	ORDER BY th.priority DESC, th.created_at ASC;
+	ORDER BY th.position ASC, th.priority DESC, th.created_at ASC;
 	`
 	queryCreateTask = `
-	INSERT INTO tasks (title, description, status, priority, due_date, parent_task_id, category_id)
-	VALUES (:title, :description, :status, :priority, :due_date, :parent_task_id, (SELECT id FROM categories WHERE name = :category_name))
-	`
-
-	queryUpdateTask = `
-	UPDATE tasks SET 
-	title = COALESCE(:title, title), 
-	description = COALESCE(:description, description), 
-	status = COALESCE(:status, status), 
-	priority = COALESCE(:priority, priority), 
-	due_date = COALESCE(:due_date, due_date), 
-	parent_task_id = COALESCE(:parent_task_id, parent_task_id), 
-	category_id = COALESCE((SELECT id FROM categories WHERE name = :category_name), category_id)
-	WHERE id = :id
+	INSERT INTO tasks (project_id, title, description, status, priority, due_date, estimate_hours, recurrence, recur_subtasks, completed_at, parent_task_id, position, category_id, assignee_id, reporter_id)
+	VALUES (:project_id, :title, :description, :status, :priority, :due_date, :estimate_hours, :recurrence, :recur_subtasks, CASE WHEN :status = 'done' THEN NOW() END, :parent_task_id, ` + queryNextPosition + `, ` + queryCategoryID + `, :assignee_id, :reporter_id)
 	`
 
+	// Deleting moves the task and its live descendants to the trash. They
+	// all get the task as their deleted_root_id, which is how RestoreTask
+	// finds the batch; deleted_at is too coarse to tell two deletes apart.
 	queryDeleteTask = `
 	WITH RECURSIVE task_hierarchy AS (
 		SELECT id, parent_task_id 
 		FROM tasks 
-		WHERE id = ?
+		WHERE id = ? AND project_id = ? AND deleted_at IS NULL
 		
 		UNION ALL
 		
 		SELECT t.id, t.parent_task_id
 		FROM tasks t
 		INNER JOIN task_hierarchy th ON t.parent_task_id = th.id
+		WHERE t.deleted_at IS NULL
 	)
-	DELETE FROM tasks 
+	UPDATE tasks SET deleted_at = NOW(), deleted_root_id = ?
 	WHERE id IN (SELECT id FROM task_hierarchy);
 	`
 )
 
-func GetAllTasks(db DBTX) ([]TaskWithCategory, error) {
+func GetAllTasks(ctx context.Context, db DBTX, filter *TaskFilter) ([]TaskWithCategory, error) {
+	return selectTasks(ctx, db, filter, filter.PageSize())
+}
+
+// GetTaskPage fetches one page of tasks along with the cursors that lead to
+// the pages around it. A cursor is only handed out when the sort can be used
+// for keyset pagination.
+func GetTaskPage(ctx context.Context, db DBTX, filter *TaskFilter) (*TaskPage, error) {
+	size := filter.PageSize()
+
+	// One extra row tells us whether there is anything beyond this page.
+	tasks, err := selectTasks(ctx, db, filter, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	hasMore := len(tasks) > size
+	if hasMore {
+		tasks = tasks[:size]
+	}
+
+	backward := filter.Cursor != nil && filter.Cursor.Backward
+	if backward {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+
+	page := &TaskPage{Tasks: tasks}
+	keys := filter.sortKeys()
+	if len(tasks) == 0 || !keysetCapable(keys) {
+		return page, nil
+	}
+
+	first, last := &tasks[0].Task, &tasks[len(tasks)-1].Task
+	if backward {
+		page.NextCursor = newCursor(keys, last, false)
+		if hasMore {
+			page.PrevCursor = newCursor(keys, first, true)
+		}
+	} else {
+		if hasMore {
+			page.NextCursor = newCursor(keys, last, false)
+		}
+		if filter.Cursor != nil || filter.Offset > 0 {
+			page.PrevCursor = newCursor(keys, first, true)
+		}
+	}
+	return page, nil
+}
+
+func selectTasks(ctx context.Context, db DBTX, filter *TaskFilter, limit int) ([]TaskWithCategory, error) {
+	conds, args := filter.conditions()
+	backward := false
+	if filter.Cursor != nil {
+		keyset, keysetArgs := filter.Cursor.keyset(filter.sortKeys())
+		conds = append(conds, keyset)
+		args = append(args, keysetArgs...)
+		backward = filter.Cursor.Backward
+	}
+
+	query := queryAllGetTasks + whereClause(conds) + filter.orderBy(backward) + " LIMIT ? OFFSET ?"
+	args = append(args, limit, filter.Offset)
+
 	var tasks []TaskWithCategory
-	err := db.Select(&tasks, queryAllGetTasks)
+	err := db.SelectContext(ctx, &tasks, query, args...)
 	return tasks, err
 }
 
-func GetTaskWithSubtasks(db DBTX, taskID int64) (*TaskHierarchy, error) {
-	// Step 1: Fetch all tasks in the hierarchy (parent and all descendants) into a flat list.
-	var flatTasks []TaskHierarchy
-	if err := db.Select(&flatTasks, queryGetTaskHierarchy, taskID); err != nil {
-		return nil, err // This could be sql.ErrNoRows or a real error
+// CountTasks returns how many tasks match the filter, ignoring paging.
+func CountTasks(ctx context.Context, db DBTX, filter *TaskFilter) (int64, error) {
+	where, args := filter.where()
+
+	var total int64
+	err := db.GetContext(ctx, &total, queryCountTasks+where, args...)
+	return total, err
+}
+
+// GetTaskWithSubtasks returns a task of the project with all its live
+// descendants. The walk never leaves the project, even if a link across
+// projects made it into the table.
+func GetTaskWithSubtasks(ctx context.Context, db DBTX, projectID, taskID int64) (*TaskHierarchy, error) {
+	flatTasks, err := getTaskHierarchy(ctx, db, projectID, taskID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Step 2: Handle the case where the root task ID doesn't exist.
+	for _, tree := range buildTaskTrees(flatTasks) {
+		if tree.ID == taskID {
+			return &tree, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// getTaskHierarchy fetches a task and all its live descendants as a flat
+// list, siblings in their position order. It returns sql.ErrNoRows when the
+// project has no such live task.
+func getTaskHierarchy(ctx context.Context, db DBTX, projectID, taskID int64) ([]TaskHierarchy, error) {
+	var flatTasks []TaskHierarchy
+	if err := db.SelectContext(ctx, &flatTasks, queryGetTaskHierarchy, taskID, projectID); err != nil {
+		return nil, err
+	}
 	if len(flatTasks) == 0 {
 		return nil, sql.ErrNoRows
 	}
+	return flatTasks, nil
+}
 
-	// Step 3: Build the tree structure from the flat list.
-	taskMap := make(map[int64]*TaskHierarchy)
+// buildTaskTrees nests every task of the flat list under its parent and
+// returns the tasks whose parent is not in the list, each with its subtree.
+// Children are collected in list order so that siblings keep their
+// position.
+func buildTaskTrees(flatTasks []TaskHierarchy) []TaskHierarchy {
+	present := make(map[int64]bool, len(flatTasks))
 	for i := range flatTasks {
-		task := &flatTasks[i]
-		taskMap[task.ID] = task
+		present[flatTasks[i].ID] = true
 	}
 
-	// Iterate through the tasks again to link children to their parents.
-	for _, task := range taskMap {
-		// Check if the task has a valid parent ID.
-		if task.ParentTaskID.Valid() {
-			// Find the parent in the map using the parent ID.
-			if parent, ok := taskMap[task.ParentTaskID.Int64Value()]; ok {
-				// **THE FIX**: Append the pointer to the task (`task`), not a copy (`*task`).
-				parent.Subtasks = append(parent.Subtasks, *task)
-			}
+	var roots []*TaskHierarchy
+	children := make(map[int64][]*TaskHierarchy)
+	for i := range flatTasks {
+		task := &flatTasks[i]
+		if task.ParentTaskID.Valid() && present[task.ParentTaskID.Int64Value()] {
+			parentID := task.ParentTaskID.Int64Value()
+			children[parentID] = append(children[parentID], task)
+			continue
 		}
+		roots = append(roots, task)
 	}
 
-	// Find the root of the tree (the task with the original ID) and return it.
-	if root, ok := taskMap[taskID]; ok {
-		return root, nil
+	// Descendants are attached deepest first, so every copy is complete.
+	trees := make([]TaskHierarchy, 0, len(roots))
+	for _, root := range roots {
+		attachSubtasks(root, children)
+		trees = append(trees, *root)
 	}
+	return trees
+}
 
-	return nil, sql.ErrNoRows
+func attachSubtasks(task *TaskHierarchy, children map[int64][]*TaskHierarchy) {
+	for _, child := range children[task.ID] {
+		attachSubtasks(child, children)
+		task.Subtasks = append(task.Subtasks, *child)
+	}
 }
 
-func CreateTask(db DBTX, req *CreateTaskRequest) (*Task, error) {
+func CreateTask(ctx context.Context, db DBTX, projectID int64, req *CreateTaskRequest, opts WriteOptions) (*Task, error) {
+	tags, err := CleanTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	var task *Task
+	err = WithTx(ctx, db, func(tx DBTX) error {
+		if req.ParentTaskID.Valid() {
+			if err := lockProject(ctx, tx, projectID); err != nil {
+				return err
+			}
+			if err := ValidateParent(ctx, tx, projectID, 0, req.ParentTaskID.Int64Value(), opts.MaxDepth); err != nil {
+				return err
+			}
+		}
+		categoryName := req.CategoryName
+		if categoryName.Valid() {
+			path, err := resolveTaskCategory(ctx, tx, projectID, categoryName.StringValue(), opts)
+			if err != nil {
+				return err
+			}
+			categoryName = null.NullStringOf(path)
+		}
+		if err := checkUsers(ctx, tx, req.AssigneeID, req.ReporterID); err != nil {
+			return err
+		}
 
-	result, err := db.NamedExec(queryCreateTask, map[string]interface{}{
-		"title":          req.Title,
-		"description":    req.Description,
-		"status":         req.Status,
-		"priority":       req.Priority,
-		"due_date":       req.DueDate,
-		"parent_task_id": req.ParentTaskID,
-		"category_name":  req.CategoryName,
+		result, err := tx.NamedExecContext(ctx, queryCreateTask, map[string]interface{}{
+			"project_id":     projectID,
+			"title":          req.Title,
+			"description":    req.Description,
+			"status":         req.Status,
+			"priority":       req.Priority,
+			"due_date":       req.DueDate,
+			"estimate_hours": req.EstimateHours,
+			"recurrence":     req.Recurrence,
+			"recur_subtasks": req.RecurSubtasks,
+			"parent_task_id": req.ParentTaskID,
+			"category_name":  categoryName,
+			"assignee_id":    req.AssigneeID,
+			"reporter_id":    req.ReporterID,
+		})
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if task, err = GetByID(ctx, tx, projectID, id); err != nil {
+			return err
+		}
+		changes, err := DiffTasks(nil, task)
+		if err != nil {
+			return err
+		}
+
+		if len(tags) > 0 {
+			if err := setTaskTags(ctx, tx, id, tags); err != nil {
+				return err
+			}
+			changes["tags"] = FieldChange{From: nil, To: tags}
+		}
+		if err := recordTaskEvent(ctx, tx, projectID, id, ActionCreate, opts.Actor, changes); err != nil {
+			return err
+		}
+		return recordOutboxEvent(ctx, tx, projectID, EventTaskCreated, id, task, changes, opts.Actor)
 	})
 	if err != nil {
 		return nil, err
 	}
+	return task, nil
+}
+
+// UpdateTask applies a patch to a live task of the project. It returns 1
+// when the task exists, even if the patch leaves it as it was, and 0 when it
+// does not.
+func UpdateTask(ctx context.Context, db DBTX, projectID int64, taskID uint64, updates *UpdateTaskRequest, opts WriteOptions) (int64, error) {
+	sets, args := updates.assignments()
+	replaceTags := updates.replacesTags()
+	if len(sets) == 0 && !replaceTags {
+		return 0, ErrNoFieldsToUpdate
+	}
+	args["id"] = taskID
+	args["project_id"] = projectID
 
-	id, err := result.LastInsertId()
+	tags, err := CleanTags(updates.Tags)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	return GetByID(db, id)
-}
+	var affected int64
+	err = WithTx(ctx, db, func(tx DBTX) error {
+		// Re-parenting takes the project lock before the task's, as every
+		// change to the tree does, so two moves cannot each pass the check
+		// and close a loop together.
+		if updates.ParentTaskID.Valid() {
+			if err := lockProject(ctx, tx, projectID); err != nil {
+				return err
+			}
+			if err := ValidateParent(ctx, tx, projectID, int64(taskID), updates.ParentTaskID.Int64Value(), opts.MaxDepth); err != nil {
+				return err
+			}
+		}
+
+		// The row stays locked, so a concurrent update cannot change the
+		// status between the checks below and the write.
+		before, err := lockTask(ctx, tx, projectID, int64(taskID))
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// From here on the task is known to exist, so the patch applies even
+		// when it changes nothing, and MySQL then reports no affected rows.
+		affected = 1
+		if updates.Status != nil {
+			if err := ValidateTransition(before.Status, *updates.Status); err != nil {
+				return err
+			}
+			if err := checkBlockers(ctx, tx, before, *updates.Status, opts); err != nil {
+				return err
+			}
+		}
+		if updates.CategoryName.Valid() {
+			path, err := resolveTaskCategory(ctx, tx, projectID, updates.CategoryName.StringValue(), opts)
+			if err != nil {
+				return err
+			}
+			args["category_name"] = null.NullStringOf(path)
+		}
+		if err := checkUsers(ctx, tx, updates.AssigneeID, updates.ReporterID); err != nil {
+			return err
+		}
+
+		if len(sets) > 0 {
+			if _, err := tx.NamedExecContext(ctx, "UPDATE tasks SET "+strings.Join(sets, ", ")+" WHERE id = :id AND project_id = :project_id AND deleted_at IS NULL", args); err != nil {
+				return err
+			}
+		}
 
-func UpdateTask(db DBTX, taskID uint64, updates *UpdateTaskRequest) (int64, error) {
-
-	res, err := db.NamedExec(queryUpdateTask, map[string]interface{}{
-		"id":             taskID,
-		"title":          updates.Title,
-		"description":    updates.Description,
-		"status":         updates.Status,
-		"priority":       updates.Priority,
-		"due_date":       updates.DueDate,
-		"completed_at":   updates.CompletedAt,
-		"parent_task_id": updates.ParentTaskID,
-		"category_name":  updates.CategoryName,
+		after, err := GetByID(ctx, tx, projectID, int64(taskID))
+		if err != nil {
+			return err
+		}
+		if before.Status != StatusDone && after.Status == StatusDone && after.Recurrence.Valid() {
+			if err := spawnNextOccurrence(ctx, tx, projectID, after, opts); err != nil {
+				return err
+			}
+			after.Recurrence = null.NullString{}
+		}
+		changes, err := DiffTasks(before, after)
+		if err != nil {
+			return err
+		}
+
+		if replaceTags {
+			previous, err := GetTaskTags(ctx, tx, int64(taskID))
+			if err != nil {
+				return err
+			}
+			if !slices.Equal(previous, tags) {
+				if err := setTaskTags(ctx, tx, int64(taskID), tags); err != nil {
+					return err
+				}
+				changes["tags"] = FieldChange{From: previous, To: tags}
+			}
+		}
+		if err := recordTaskEvent(ctx, tx, projectID, int64(taskID), ActionUpdate, opts.Actor, changes); err != nil {
+			return err
+		}
+		return recordOutboxEvent(ctx, tx, projectID, updateEvent(changes), int64(taskID), after, changes, opts.Actor)
 	})
 	if err != nil {
 		return 0, err
 	}
-	return res.RowsAffected()
+	return affected, nil
 }
 
-func DeleteTask(db DBTX, taskID uint64) (int64, error) {
-	req, err := db.Exec(queryDeleteTask, taskID)
+func DeleteTask(ctx context.Context, db DBTX, projectID int64, taskID uint64, opts WriteOptions) (int64, error) {
+	var affected int64
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		res, err := tx.ExecContext(ctx, queryDeleteTask, taskID, projectID, taskID)
+		if err != nil {
+			return err
+		}
+		if affected, err = res.RowsAffected(); err != nil || affected == 0 {
+			return err
+		}
+
+		var deletedAt null.NullTime
+		if err := tx.GetContext(ctx, &deletedAt, queryGetDeletedAt, taskID); err != nil {
+			return err
+		}
+		changes := Changes{"deleted_at": {From: nil, To: deletedAt}}
+		if err := recordTaskEvent(ctx, tx, projectID, int64(taskID), ActionDelete, opts.Actor, changes); err != nil {
+			return err
+		}
+		return recordOutboxEvent(ctx, tx, projectID, EventTaskDeleted, int64(taskID), nil, changes, opts.Actor)
+	})
 	if err != nil {
 		return 0, err
 	}
-	return req.RowsAffected()
+	return affected, nil
 }
 
-func GetByID(db DBTX, taskID int64) (*Task, error) {
-	query := `
-        SELECT id, title, description, status, priority, due_date, 
-               completed_at, parent_task_id, category_id, created_at, updated_at
-        FROM tasks WHERE id = ?`
+const queryGetTaskByID = `
+        SELECT id, project_id, title, description, status, priority, due_date, estimate_hours,
+               recurrence, recur_subtasks, completed_at, parent_task_id, position, category_id, assignee_id, reporter_id,
+               created_at, updated_at, deleted_at
+        FROM tasks WHERE id = ? AND project_id = ? AND deleted_at IS NULL`
 
+// GetByID returns a live task of the project, or sql.ErrNoRows when the
+// project has no such task.
+func GetByID(ctx context.Context, db DBTX, projectID, taskID int64) (*Task, error) {
 	var task Task
-	err := db.Get(&task, query, taskID)
+	err := db.GetContext(ctx, &task, queryGetTaskByID, taskID, projectID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &task, nil
 }
+
+// lockTask reads a task and locks its row until the transaction ends, so
+// that checks made against it still hold when the change is written.
+func lockTask(ctx context.Context, tx DBTX, projectID, taskID int64) (*Task, error) {
+	var task Task
+	if err := tx.GetContext(ctx, &task, queryGetTaskByID+" FOR UPDATE", taskID, projectID); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
@@ -5,6 +5,7 @@
 package model
 
 import (
+	"context"
 	"database/sql"
 
 	mock "github.com/stretchr/testify/mock"
@@ -37,291 +38,315 @@ func (_m *MockDBTX) EXPECT() *MockDBTX_Expecter {
 	return &MockDBTX_Expecter{mock: &_m.Mock}
 }
 
-// Exec provides a mock function for the type MockDBTX
-func (_mock *MockDBTX) Exec(query string, args ...interface{}) (sql.Result, error) {
+// ExecContext provides a mock function for the type MockDBTX
+func (_mock *MockDBTX) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	var tmpRet mock.Arguments
 	if len(args) > 0 {
-		tmpRet = _mock.Called(query, args)
+		tmpRet = _mock.Called(ctx, query, args)
 	} else {
-		tmpRet = _mock.Called(query)
+		tmpRet = _mock.Called(ctx, query)
 	}
 	ret := tmpRet
 
 	if len(ret) == 0 {
-		panic("no return value specified for Exec")
+		panic("no return value specified for ExecContext")
 	}
 
 	var r0 sql.Result
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string, ...interface{}) (sql.Result, error)); ok {
-		return returnFunc(query, args...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, ...interface{}) (sql.Result, error)); ok {
+		return returnFunc(ctx, query, args...)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string, ...interface{}) sql.Result); ok {
-		r0 = returnFunc(query, args...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, ...interface{}) sql.Result); ok {
+		r0 = returnFunc(ctx, query, args...)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(sql.Result)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string, ...interface{}) error); ok {
-		r1 = returnFunc(query, args...)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, ...interface{}) error); ok {
+		r1 = returnFunc(ctx, query, args...)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockDBTX_Exec_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Exec'
-type MockDBTX_Exec_Call struct {
+// MockDBTX_ExecContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ExecContext'
+type MockDBTX_ExecContext_Call struct {
 	*mock.Call
 }
 
-// Exec is a helper method to define mock.On call
+// ExecContext is a helper method to define mock.On call
+//   - ctx context.Context
 //   - query string
 //   - args ...interface{}
-func (_e *MockDBTX_Expecter) Exec(query interface{}, args ...interface{}) *MockDBTX_Exec_Call {
-	return &MockDBTX_Exec_Call{Call: _e.mock.On("Exec",
-		append([]interface{}{query}, args...)...)}
+func (_e *MockDBTX_Expecter) ExecContext(ctx interface{}, query interface{}, args ...interface{}) *MockDBTX_ExecContext_Call {
+	return &MockDBTX_ExecContext_Call{Call: _e.mock.On("ExecContext",
+		append([]interface{}{ctx, query}, args...)...)}
 }
 
-func (_c *MockDBTX_Exec_Call) Run(run func(query string, args ...interface{})) *MockDBTX_Exec_Call {
+func (_c *MockDBTX_ExecContext_Call) Run(run func(ctx context.Context, query string, args ...interface{})) *MockDBTX_ExecContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 []interface{}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 []interface{}
 		var variadicArgs []interface{}
-		if len(args) > 1 {
-			variadicArgs = args[1].([]interface{})
+		if len(args) > 2 {
+			variadicArgs = args[2].([]interface{})
 		}
-		arg1 = variadicArgs
+		arg2 = variadicArgs
 		run(
 			arg0,
-			arg1...,
+			arg1,
+			arg2...,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBTX_Exec_Call) Return(result sql.Result, err error) *MockDBTX_Exec_Call {
+func (_c *MockDBTX_ExecContext_Call) Return(result sql.Result, err error) *MockDBTX_ExecContext_Call {
 	_c.Call.Return(result, err)
 	return _c
 }
 
-func (_c *MockDBTX_Exec_Call) RunAndReturn(run func(query string, args ...interface{}) (sql.Result, error)) *MockDBTX_Exec_Call {
+func (_c *MockDBTX_ExecContext_Call) RunAndReturn(run func(ctx context.Context, query string, args ...interface{}) (sql.Result, error)) *MockDBTX_ExecContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Get provides a mock function for the type MockDBTX
-func (_mock *MockDBTX) Get(dest interface{}, query string, args ...interface{}) error {
+// GetContext provides a mock function for the type MockDBTX
+func (_mock *MockDBTX) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 	var tmpRet mock.Arguments
 	if len(args) > 0 {
-		tmpRet = _mock.Called(dest, query, args)
+		tmpRet = _mock.Called(ctx, dest, query, args)
 	} else {
-		tmpRet = _mock.Called(dest, query)
+		tmpRet = _mock.Called(ctx, dest, query)
 	}
 	ret := tmpRet
 
 	if len(ret) == 0 {
-		panic("no return value specified for Get")
+		panic("no return value specified for GetContext")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(interface{}, string, ...interface{}) error); ok {
-		r0 = returnFunc(dest, query, args...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, interface{}, string, ...interface{}) error); ok {
+		r0 = returnFunc(ctx, dest, query, args...)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockDBTX_Get_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Get'
-type MockDBTX_Get_Call struct {
+// MockDBTX_GetContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetContext'
+type MockDBTX_GetContext_Call struct {
 	*mock.Call
 }
 
-// Get is a helper method to define mock.On call
+// GetContext is a helper method to define mock.On call
+//   - ctx context.Context
 //   - dest interface{}
 //   - query string
 //   - args ...interface{}
-func (_e *MockDBTX_Expecter) Get(dest interface{}, query interface{}, args ...interface{}) *MockDBTX_Get_Call {
-	return &MockDBTX_Get_Call{Call: _e.mock.On("Get",
-		append([]interface{}{dest, query}, args...)...)}
+func (_e *MockDBTX_Expecter) GetContext(ctx interface{}, dest interface{}, query interface{}, args ...interface{}) *MockDBTX_GetContext_Call {
+	return &MockDBTX_GetContext_Call{Call: _e.mock.On("GetContext",
+		append([]interface{}{ctx, dest, query}, args...)...)}
 }
 
-func (_c *MockDBTX_Get_Call) Run(run func(dest interface{}, query string, args ...interface{})) *MockDBTX_Get_Call {
+func (_c *MockDBTX_GetContext_Call) Run(run func(ctx context.Context, dest interface{}, query string, args ...interface{})) *MockDBTX_GetContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 interface{}
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(interface{})
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 interface{}
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(interface{})
 		}
-		var arg2 []interface{}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []interface{}
 		var variadicArgs []interface{}
-		if len(args) > 2 {
-			variadicArgs = args[2].([]interface{})
+		if len(args) > 3 {
+			variadicArgs = args[3].([]interface{})
 		}
-		arg2 = variadicArgs
+		arg3 = variadicArgs
 		run(
 			arg0,
 			arg1,
-			arg2...,
+			arg2,
+			arg3...,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBTX_Get_Call) Return(err error) *MockDBTX_Get_Call {
+func (_c *MockDBTX_GetContext_Call) Return(err error) *MockDBTX_GetContext_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockDBTX_Get_Call) RunAndReturn(run func(dest interface{}, query string, args ...interface{}) error) *MockDBTX_Get_Call {
+func (_c *MockDBTX_GetContext_Call) RunAndReturn(run func(ctx context.Context, dest interface{}, query string, args ...interface{}) error) *MockDBTX_GetContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// NamedExec provides a mock function for the type MockDBTX
-func (_mock *MockDBTX) NamedExec(query string, arg interface{}) (sql.Result, error) {
-	ret := _mock.Called(query, arg)
+// NamedExecContext provides a mock function for the type MockDBTX
+func (_mock *MockDBTX) NamedExecContext(ctx context.Context, query string, arg interface{}) (sql.Result, error) {
+	ret := _mock.Called(ctx, query, arg)
 
 	if len(ret) == 0 {
-		panic("no return value specified for NamedExec")
+		panic("no return value specified for NamedExecContext")
 	}
 
 	var r0 sql.Result
 	var r1 error
-	if returnFunc, ok := ret.Get(0).(func(string, interface{}) (sql.Result, error)); ok {
-		return returnFunc(query, arg)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, interface{}) (sql.Result, error)); ok {
+		return returnFunc(ctx, query, arg)
 	}
-	if returnFunc, ok := ret.Get(0).(func(string, interface{}) sql.Result); ok {
-		r0 = returnFunc(query, arg)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, interface{}) sql.Result); ok {
+		r0 = returnFunc(ctx, query, arg)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(sql.Result)
 		}
 	}
-	if returnFunc, ok := ret.Get(1).(func(string, interface{}) error); ok {
-		r1 = returnFunc(query, arg)
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, interface{}) error); ok {
+		r1 = returnFunc(ctx, query, arg)
 	} else {
 		r1 = ret.Error(1)
 	}
 	return r0, r1
 }
 
-// MockDBTX_NamedExec_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NamedExec'
-type MockDBTX_NamedExec_Call struct {
+// MockDBTX_NamedExecContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'NamedExecContext'
+type MockDBTX_NamedExecContext_Call struct {
 	*mock.Call
 }
 
-// NamedExec is a helper method to define mock.On call
+// NamedExecContext is a helper method to define mock.On call
+//   - ctx context.Context
 //   - query string
 //   - arg interface{}
-func (_e *MockDBTX_Expecter) NamedExec(query interface{}, arg interface{}) *MockDBTX_NamedExec_Call {
-	return &MockDBTX_NamedExec_Call{Call: _e.mock.On("NamedExec", query, arg)}
+func (_e *MockDBTX_Expecter) NamedExecContext(ctx interface{}, query interface{}, arg interface{}) *MockDBTX_NamedExecContext_Call {
+	return &MockDBTX_NamedExecContext_Call{Call: _e.mock.On("NamedExecContext", ctx, query, arg)}
 }
 
-func (_c *MockDBTX_NamedExec_Call) Run(run func(query string, arg interface{})) *MockDBTX_NamedExec_Call {
+func (_c *MockDBTX_NamedExecContext_Call) Run(run func(ctx context.Context, query string, arg interface{})) *MockDBTX_NamedExecContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 string
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(string)
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 interface{}
+		var arg1 string
 		if args[1] != nil {
-			arg1 = args[1].(interface{})
+			arg1 = args[1].(string)
+		}
+		var arg2 interface{}
+		if args[2] != nil {
+			arg2 = args[2].(interface{})
 		}
 		run(
 			arg0,
 			arg1,
+			arg2,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBTX_NamedExec_Call) Return(result sql.Result, err error) *MockDBTX_NamedExec_Call {
+func (_c *MockDBTX_NamedExecContext_Call) Return(result sql.Result, err error) *MockDBTX_NamedExecContext_Call {
 	_c.Call.Return(result, err)
 	return _c
 }
 
-func (_c *MockDBTX_NamedExec_Call) RunAndReturn(run func(query string, arg interface{}) (sql.Result, error)) *MockDBTX_NamedExec_Call {
+func (_c *MockDBTX_NamedExecContext_Call) RunAndReturn(run func(ctx context.Context, query string, arg interface{}) (sql.Result, error)) *MockDBTX_NamedExecContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Select provides a mock function for the type MockDBTX
-func (_mock *MockDBTX) Select(dest interface{}, query string, args ...interface{}) error {
+// SelectContext provides a mock function for the type MockDBTX
+func (_mock *MockDBTX) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
 	var tmpRet mock.Arguments
 	if len(args) > 0 {
-		tmpRet = _mock.Called(dest, query, args)
+		tmpRet = _mock.Called(ctx, dest, query, args)
 	} else {
-		tmpRet = _mock.Called(dest, query)
+		tmpRet = _mock.Called(ctx, dest, query)
 	}
 	ret := tmpRet
 
 	if len(ret) == 0 {
-		panic("no return value specified for Select")
+		panic("no return value specified for SelectContext")
 	}
 
 	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(interface{}, string, ...interface{}) error); ok {
-		r0 = returnFunc(dest, query, args...)
+	if returnFunc, ok := ret.Get(0).(func(context.Context, interface{}, string, ...interface{}) error); ok {
+		r0 = returnFunc(ctx, dest, query, args...)
 	} else {
 		r0 = ret.Error(0)
 	}
 	return r0
 }
 
-// MockDBTX_Select_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Select'
-type MockDBTX_Select_Call struct {
+// MockDBTX_SelectContext_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SelectContext'
+type MockDBTX_SelectContext_Call struct {
 	*mock.Call
 }
 
-// Select is a helper method to define mock.On call
+// SelectContext is a helper method to define mock.On call
+//   - ctx context.Context
 //   - dest interface{}
 //   - query string
 //   - args ...interface{}
-func (_e *MockDBTX_Expecter) Select(dest interface{}, query interface{}, args ...interface{}) *MockDBTX_Select_Call {
-	return &MockDBTX_Select_Call{Call: _e.mock.On("Select",
-		append([]interface{}{dest, query}, args...)...)}
+func (_e *MockDBTX_Expecter) SelectContext(ctx interface{}, dest interface{}, query interface{}, args ...interface{}) *MockDBTX_SelectContext_Call {
+	return &MockDBTX_SelectContext_Call{Call: _e.mock.On("SelectContext",
+		append([]interface{}{ctx, dest, query}, args...)...)}
 }
 
-func (_c *MockDBTX_Select_Call) Run(run func(dest interface{}, query string, args ...interface{})) *MockDBTX_Select_Call {
+func (_c *MockDBTX_SelectContext_Call) Run(run func(ctx context.Context, dest interface{}, query string, args ...interface{})) *MockDBTX_SelectContext_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		var arg0 interface{}
+		var arg0 context.Context
 		if args[0] != nil {
-			arg0 = args[0].(interface{})
+			arg0 = args[0].(context.Context)
 		}
-		var arg1 string
+		var arg1 interface{}
 		if args[1] != nil {
-			arg1 = args[1].(string)
+			arg1 = args[1].(interface{})
 		}
-		var arg2 []interface{}
+		var arg2 string
+		if args[2] != nil {
+			arg2 = args[2].(string)
+		}
+		var arg3 []interface{}
 		var variadicArgs []interface{}
-		if len(args) > 2 {
-			variadicArgs = args[2].([]interface{})
+		if len(args) > 3 {
+			variadicArgs = args[3].([]interface{})
 		}
-		arg2 = variadicArgs
+		arg3 = variadicArgs
 		run(
 			arg0,
 			arg1,
-			arg2...,
+			arg2,
+			arg3...,
 		)
 	})
 	return _c
 }
 
-func (_c *MockDBTX_Select_Call) Return(err error) *MockDBTX_Select_Call {
+func (_c *MockDBTX_SelectContext_Call) Return(err error) *MockDBTX_SelectContext_Call {
 	_c.Call.Return(err)
 	return _c
 }
 
-func (_c *MockDBTX_Select_Call) RunAndReturn(run func(dest interface{}, query string, args ...interface{}) error) *MockDBTX_Select_Call {
+func (_c *MockDBTX_SelectContext_Call) RunAndReturn(run func(ctx context.Context, dest interface{}, query string, args ...interface{}) error) *MockDBTX_SelectContext_Call {
 	_c.Call.Return(run)
 	return _c
 }
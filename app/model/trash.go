@@ -0,0 +1,174 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+var ErrParentTrashed = errors.New("parent task is in the trash, restore it first")
+
+const (
+	queryGetTrash = `
+		SELECT 
+			t.id, t.title, t.description, t.status, t.priority, 
+			t.due_date, t.estimate_hours, t.recurrence, t.recur_subtasks, t.completed_at, t.parent_task_id, t.position, t.category_id,
+			t.assignee_id, t.reporter_id,
+			t.created_at, t.updated_at, t.deleted_at, c.path as category_name,
+			` + queryTagsOf + `t.id) AS tags
+		FROM tasks t
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE t.project_id = ? AND t.deleted_at IS NOT NULL
+		ORDER BY t.deleted_at DESC, t.id ASC
+	`
+
+	queryGetTrashedTask = `
+		SELECT id, project_id, parent_task_id, deleted_at, COALESCE(deleted_root_id, id) AS deleted_root_id
+		FROM tasks
+		WHERE id = ? AND project_id = ? AND deleted_at IS NOT NULL
+	`
+
+	queryGetDeletedAt = `SELECT deleted_at FROM tasks WHERE id = ?`
+
+	// queryTrashedSubtree lists a task and the descendants trashed together
+	// with it. Anything deleted on its own before that stays in the trash,
+	// even when it was deleted in the same second.
+	queryTrashedSubtree = `
+	WITH RECURSIVE task_hierarchy AS (
+		SELECT id
+		FROM tasks
+		WHERE id = ?
+
+		UNION ALL
+
+		SELECT t.id
+		FROM tasks t
+		INNER JOIN task_hierarchy th ON t.parent_task_id = th.id
+		WHERE t.deleted_root_id = ?
+	)
+	SELECT id FROM task_hierarchy
+	`
+
+	// queryRestoreTasks is completed with placeholders for the tasks.
+	queryRestoreTasks = `UPDATE tasks SET deleted_at = NULL, deleted_root_id = NULL WHERE id IN (%s)`
+
+	// queryPurgeTrash removes the tasks that have been in the trash for
+	// longer than the retention. The cutoff is computed by the database,
+	// which also stamped deleted_at, with the same value for every task of a
+	// delete, so the tasks trashed together expire together. A task stays,
+	// whatever its age, while anything below it stays, live or trashed
+	// later: removing it would take its descendants with it through the ON
+	// DELETE CASCADE on parent_task_id.
+	queryPurgeTrash = `
+	WITH RECURSIVE kept AS (
+		SELECT parent_task_id AS id
+		FROM tasks
+		WHERE project_id = ? AND parent_task_id IS NOT NULL
+		AND (deleted_at IS NULL OR deleted_at >= NOW() - INTERVAL ? SECOND)
+
+		UNION
+
+		SELECT t.parent_task_id
+		FROM tasks t
+		INNER JOIN kept k ON t.id = k.id
+		WHERE t.parent_task_id IS NOT NULL
+	)
+	DELETE FROM tasks
+	WHERE project_id = ? AND deleted_at IS NOT NULL AND deleted_at < NOW() - INTERVAL ? SECOND
+	AND id NOT IN (SELECT id FROM kept)
+	`
+)
+
+// GetTrash lists every task of the project in the trash, most recently
+// deleted first.
+func GetTrash(ctx context.Context, db DBTX, projectID int64) ([]TaskWithCategory, error) {
+	var tasks []TaskWithCategory
+	err := db.SelectContext(ctx, &tasks, queryGetTrash, projectID)
+	return tasks, err
+}
+
+// RestoreTask takes a task and the subtree deleted with it out of the trash,
+// placing the task after its live siblings.
+// It returns sql.ErrNoRows when the task is not in the trash and
+// ErrParentTrashed when it would come back under a deleted parent.
+func RestoreTask(ctx context.Context, db DBTX, projectID, taskID int64, opts WriteOptions) (int64, error) {
+	var restored int64
+	err := WithTx(ctx, db, func(tx DBTX) error {
+		// The task comes back into the tree, after its live siblings, so
+		// the tree is locked as for a move.
+		if err := lockProject(ctx, tx, projectID); err != nil {
+			return err
+		}
+		var task Task
+		if err := tx.GetContext(ctx, &task, queryGetTrashedTask, taskID, projectID); err != nil {
+			return err
+		}
+
+		if task.ParentTaskID.Valid() {
+			var parentDeletedAt null.NullTime
+			if err := tx.GetContext(ctx, &parentDeletedAt, queryGetDeletedAt, task.ParentTaskID.Int64Value()); err != nil {
+				return err
+			}
+			if parentDeletedAt.Valid() {
+				return ErrParentTrashed
+			}
+		}
+
+		var ids []int64
+		if err := tx.SelectContext(ctx, &ids, queryTrashedSubtree, taskID, task.DeletedRootID.Int64Value()); err != nil {
+			return err
+		}
+		args := make([]interface{}, len(ids))
+		for i, id := range ids {
+			args[i] = id
+		}
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(queryRestoreTasks, placeholders(len(ids))), args...)
+		if err != nil {
+			return err
+		}
+		if restored, err = res.RowsAffected(); err != nil {
+			return err
+		}
+
+		// Its old position may have been taken since it was deleted.
+		var siblings []int64
+		if err := tx.SelectContext(ctx, &siblings, querySiblingIDs, projectID, task.ParentTaskID, taskID); err != nil {
+			return err
+		}
+		if err := renumber(ctx, tx, append(siblings, taskID)); err != nil {
+			return err
+		}
+
+		changes := Changes{"deleted_at": {From: task.DeletedAt, To: nil}}
+		if err := recordTaskEvent(ctx, tx, projectID, taskID, ActionRestore, opts.Actor, changes); err != nil {
+			return err
+		}
+		// Every task that comes back is announced, as a subscriber may
+		// follow any of them.
+		for _, id := range ids {
+			restoredTask, err := GetByID(ctx, tx, projectID, id)
+			if err != nil {
+				return err
+			}
+			if err := recordOutboxEvent(ctx, tx, projectID, EventTaskRestored, id, restoredTask, changes, opts.Actor); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return restored, err
+}
+
+// PurgeTrash permanently removes the project's tasks that have been in the
+// trash for longer than retention.
+func PurgeTrash(ctx context.Context, db DBTX, projectID int64, retention time.Duration) (int64, error) {
+	seconds := int64(retention / time.Second)
+	res, err := db.ExecContext(ctx, queryPurgeTrash, projectID, seconds, projectID, seconds)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,141 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestRestoreTask_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	deletedAt := nulltype.NullTimeOf(time.Now())
+	expectProjectLock(mockDB)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 4, ParentTaskID: nulltype.NullInt64Of(1), DeletedAt: deletedAt, DeletedRootID: nulltype.NullInt64Of(4)}
+		}).
+		Return(nil).Once()
+
+	// The parent is live
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Return(nil)
+
+	// The batch is matched on the task whose delete trashed it, not on
+	// deleted_at
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(4)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]int64) = []int64{4, 6, 7}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(4), int64(6), int64(7)}).
+		Return(&mockResult{rowsAffected: 3}, nil)
+
+	// Task 5 took position 0 while task 4 was in the trash, so task 4 comes
+	// back after it.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), nulltype.NullInt64Of(1), int64(4)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*[]int64) = []int64{5}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(5), 0, int64(4), 1, int64(5), int64(4)}).
+		Return(&mockResult{rowsAffected: 2}, nil)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, model.ActionRestore, args[2])
+		}).
+		Return(&mockResult{lastInsertID: 1}, nil).Once()
+
+	// Each task that comes back is announced in the outbox
+	var announced []interface{}
+	for _, id := range []int64{4, 6, 7} {
+		id := id
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{id, int64(1)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*model.Task) = model.Task{ID: id, ProjectID: 1}
+			}).
+			Return(nil).Once()
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, mock.Anything).
+			Run(func(ctx context.Context, query string, args ...interface{}) {
+				assert.Equal(t, model.EventTaskRestored, args[2])
+				announced = append(announced, args[1])
+			}).
+			Return(&mockResult{lastInsertID: id}, nil).Once()
+	}
+
+	restored, err := model.RestoreTask(context.Background(), mockDB, 1, 4, model.WriteOptions{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), restored)
+	assert.DeepEqual(t, []interface{}{int64(4), int64(6), int64(7)}, announced)
+}
+
+func TestRestoreTask_ParentTrashed(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	expectProjectLock(mockDB)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Task) = model.Task{ID: 4, ParentTaskID: nulltype.NullInt64Of(1)}
+		}).
+		Return(nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*nulltype.NullTime) = nulltype.NullTimeOf(time.Now())
+		}).
+		Return(nil)
+
+	_, err := model.RestoreTask(context.Background(), mockDB, 1, 4, model.WriteOptions{})
+
+	assert.Equal(t, model.ErrParentTrashed, err)
+}
+
+func TestRestoreTask_NotInTrash(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	expectProjectLock(mockDB)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(4), int64(1)}).
+		Return(sql.ErrNoRows)
+
+	_, err := model.RestoreTask(context.Background(), mockDB, 1, 4, model.WriteOptions{})
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestPurgeTrash_Success(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{int64(1), int64(86400), int64(1), int64(86400)}).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			// Ancestors of what stays are kept, so the cascade only takes
+			// expired tasks.
+			assert.True(t, strings.Contains(query, "id NOT IN (SELECT id FROM kept)"))
+		}).
+		Return(&mockResult{rowsAffected: 5}, nil)
+
+	purged, err := model.PurgeTrash(context.Background(), mockDB, 1, 24*time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), purged)
+}
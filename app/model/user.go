@@ -1,10 +1,204 @@
 package model
 
-import "time"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+const (
+	// maxUserName and maxUserEmail match the widths of users.name and
+	// users.email.
+	maxUserName  = 100
+	maxUserEmail = 255
+)
+
+var (
+	ErrInvalidUserName = errors.New("user names must be 1 to 100 characters")
+	ErrInvalidEmail    = errors.New("invalid email address")
+	ErrUserExists      = errors.New("a user with this email already exists")
+	ErrUnknownUser     = errors.New("unknown user")
+)
 
 type User struct {
-	ID        int       `db:"id" json:"id"`
+	ID        int64     `db:"id" json:"id"`
 	Name      string    `db:"name" json:"name"`
 	Email     string    `db:"email" json:"email"`
+	Role      Role      `db:"role" json:"role"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
+
+// UserRequest creates a user or, as a merge patch, updates one: fields left
+// out of a PATCH keep their value. A new user without a role is a member.
+type UserRequest struct {
+	Name  null.NullString `json:"name"`
+	Email null.NullString `json:"email"`
+	Role  *Role           `json:"role"`
+}
+
+// Validate trims the fields that are present and checks them. A new user
+// needs both.
+func (r *UserRequest) Validate(create bool) error {
+	if create && (!r.Name.Valid() || !r.Email.Valid()) {
+		return errors.New("name and email are required")
+	}
+	if r.Name.Valid() {
+		name := strings.TrimSpace(r.Name.StringValue())
+		if name == "" || utf8.RuneCountInString(name) > maxUserName {
+			return ErrInvalidUserName
+		}
+		r.Name = null.NullStringOf(name)
+	}
+	if r.Email.Valid() {
+		email := strings.ToLower(strings.TrimSpace(r.Email.StringValue()))
+		addr, err := mail.ParseAddress(email)
+		if err != nil || addr.Address != email || len(email) > maxUserEmail {
+			return ErrInvalidEmail
+		}
+		r.Email = null.NullStringOf(email)
+	}
+	if r.Role != nil && !r.Role.IsValid() {
+		return ErrInvalidRole
+	}
+	return nil
+}
+
+const (
+	queryGetUsers = `SELECT id, name, email, role, created_at FROM users ORDER BY id`
+
+	queryGetUser = `SELECT id, name, email, role, created_at FROM users WHERE id = ?`
+
+	queryGetUserByEmail = `SELECT id, name, email, role, created_at FROM users WHERE email = ?`
+
+	queryCountUsers = `SELECT COUNT(*) FROM users WHERE id IN (%s)`
+
+	queryCreateUser = `INSERT INTO users (name, email, role) VALUES (?, ?, ?)`
+
+	// Tasks assigned to or reported by the user are kept and lose the link
+	// through the ON DELETE SET NULL on tasks.assignee_id and reporter_id.
+	queryDeleteUser = `DELETE FROM users WHERE id = ?`
+)
+
+func GetUsers(ctx context.Context, db DBTX) ([]User, error) {
+	users := []User{}
+	err := db.SelectContext(ctx, &users, queryGetUsers)
+	return users, err
+}
+
+func GetUserByID(ctx context.Context, db DBTX, userID int64) (*User, error) {
+	var user User
+	if err := db.GetContext(ctx, &user, queryGetUser, userID); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUser looks a user up by id or by email, which is how the X-User
+// header names the caller. It returns sql.ErrNoRows when nobody matches.
+func FindUser(ctx context.Context, db DBTX, ref string) (*User, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		return GetUserByID(ctx, db, id)
+	}
+
+	var user User
+	if err := db.GetContext(ctx, &user, queryGetUserByEmail, strings.ToLower(ref)); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func CreateUser(ctx context.Context, db DBTX, req *UserRequest) (*User, error) {
+	role := RoleMember
+	if req.Role != nil {
+		role = *req.Role
+	}
+	result, err := db.ExecContext(ctx, queryCreateUser, req.Name.StringValue(), req.Email.StringValue(), role)
+	if err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrUserExists
+		}
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return GetUserByID(ctx, db, id)
+}
+
+// UpdateUser applies a validated patch and returns the user as it is
+// afterwards, or sql.ErrNoRows when there is no such user.
+func UpdateUser(ctx context.Context, db DBTX, userID int64, req *UserRequest) (*User, error) {
+	var (
+		sets []string
+		args []interface{}
+	)
+	if req.Name.Valid() {
+		sets = append(sets, "name = ?")
+		args = append(args, req.Name.StringValue())
+	}
+	if req.Email.Valid() {
+		sets = append(sets, "email = ?")
+		args = append(args, req.Email.StringValue())
+	}
+	if req.Role != nil {
+		sets = append(sets, "role = ?")
+		args = append(args, *req.Role)
+	}
+	if len(sets) == 0 {
+		return nil, ErrNoFieldsToUpdate
+	}
+
+	query := "UPDATE users SET " + strings.Join(sets, ", ") + " WHERE id = ?"
+	if _, err := db.ExecContext(ctx, query, append(args, userID)...); err != nil {
+		if isDuplicateKey(err) {
+			return nil, ErrUserExists
+		}
+		return nil, err
+	}
+	// Setting the current values affects no rows, so read the user back
+	// instead of trusting the row count.
+	return GetUserByID(ctx, db, userID)
+}
+
+func DeleteUser(ctx context.Context, db DBTX, userID int64) (int64, error) {
+	result, err := db.ExecContext(ctx, queryDeleteUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// checkUsers returns ErrUnknownUser unless every valid id names a user.
+func checkUsers(ctx context.Context, db DBTX, ids ...null.NullInt64) error {
+	// The same user may well be both assignee and reporter.
+	seen := map[int64]bool{}
+	var args []interface{}
+	for _, id := range ids {
+		if id.Valid() && !seen[id.Int64Value()] {
+			seen[id.Int64Value()] = true
+			args = append(args, id.Int64Value())
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	var found int
+	if err := db.GetContext(ctx, &found, fmt.Sprintf(queryCountUsers, placeholders(len(args))), args...); err != nil {
+		return err
+	}
+	if found != len(args) {
+		return ErrUnknownUser
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package model_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestUserRequest_Validate(t *testing.T) {
+	req := &model.UserRequest{Name: nulltype.NullStringOf(" Alice "), Email: nulltype.NullStringOf("Alice@Example.com")}
+	assert.NoError(t, req.Validate(true))
+	assert.Equal(t, "Alice", req.Name.StringValue())
+	assert.Equal(t, "alice@example.com", req.Email.StringValue())
+
+	assert.Error(t, (&model.UserRequest{Name: nulltype.NullStringOf("Alice")}).Validate(true))
+	assert.NoError(t, (&model.UserRequest{Name: nulltype.NullStringOf("Alice")}).Validate(false))
+	assert.Equal(t, model.ErrInvalidUserName, (&model.UserRequest{Name: nulltype.NullStringOf("  ")}).Validate(false))
+	for _, email := range []string{"alice", "Alice <alice@example.com>", "@example.com"} {
+		assert.Equal(t, model.ErrInvalidEmail, (&model.UserRequest{Email: nulltype.NullStringOf(email)}).Validate(false))
+	}
+}
+
+func TestFindUser(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, "SELECT id, name, email, role, created_at FROM users WHERE id = ?", []interface{}{int64(7)}).
+		Return(nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, "SELECT id, name, email, role, created_at FROM users WHERE email = ?", []interface{}{"alice@example.com"}).
+		Return(nil)
+
+	_, err := model.FindUser(context.Background(), mockDB, "7")
+	assert.NoError(t, err)
+	_, err = model.FindUser(context.Background(), mockDB, "Alice@example.com")
+	assert.NoError(t, err)
+}
+
+func TestCreateUser_Duplicate(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, []interface{}{"Alice", "alice@example.com", model.RoleMember}).
+		Return(nil, &mysql.MySQLError{Number: 1062})
+
+	req := &model.UserRequest{Name: nulltype.NullStringOf("Alice"), Email: nulltype.NullStringOf("alice@example.com")}
+	_, err := model.CreateUser(context.Background(), mockDB, req)
+	assert.Equal(t, model.ErrUserExists, err)
+}
+
+func TestCreateTask_UnknownAssignee(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	// Assignee and reporter are the same user, so one match is enough.
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, "SELECT COUNT(*) FROM users WHERE id IN (?)", []interface{}{int64(9)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*int) = 0
+		}).
+		Return(nil)
+
+	req := &model.CreateTaskRequest{
+		Title:      "New Task",
+		AssigneeID: nulltype.NullInt64Of(9),
+		ReporterID: nulltype.NullInt64Of(9),
+	}
+	_, err := model.CreateTask(context.Background(), mockDB, 1, req, model.WriteOptions{})
+	assert.Equal(t, model.ErrUnknownUser, err)
+}
+
+func TestGetAllTasks_Assignee(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), int64(3), model.DefaultTaskLimit, 0}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "t.assignee_id = ?"))
+		}).
+		Return(nil).Once()
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(1), model.DefaultTaskLimit, 0}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			assert.True(t, strings.Contains(query, "t.assignee_id IS NULL"))
+		}).
+		Return(nil).Once()
+
+	_, err := model.GetAllTasks(context.Background(), mockDB, &model.TaskFilter{ProjectID: 1, AssigneeID: nulltype.NullInt64Of(3)})
+	assert.NoError(t, err)
+	_, err = model.GetAllTasks(context.Background(), mockDB, &model.TaskFilter{ProjectID: 1, Unassigned: true})
+	assert.NoError(t, err)
+}
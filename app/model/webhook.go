@@ -0,0 +1,423 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	null "github.com/mattn/go-nulltype"
+)
+
+// WebhookEvents is the SET column of the events a webhook receives.
+type WebhookEvents []EventType
+
+func (l *WebhookEvents) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []byte:
+		raw = string(v)
+	case string:
+		raw = v
+	default:
+		return fmt.Errorf("cannot scan %T into WebhookEvents", value)
+	}
+	*l = nil
+	for _, event := range strings.Split(raw, ",") {
+		if event != "" {
+			*l = append(*l, EventType(event))
+		}
+	}
+	return nil
+}
+
+func (l WebhookEvents) Value() (driver.Value, error) {
+	events := make([]string, len(l))
+	for i, event := range l {
+		events[i] = string(event)
+	}
+	return strings.Join(events, ","), nil
+}
+
+// RawJSON is a JSON column passed through untouched.
+type RawJSON json.RawMessage
+
+func (j *RawJSON) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append(RawJSON(nil), v...)
+	case string:
+		*j = RawJSON(v)
+	default:
+		return fmt.Errorf("cannot scan %T into RawJSON", value)
+	}
+	return nil
+}
+
+func (j RawJSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+const (
+	// maxWebhookURL matches the width of webhooks.url.
+	maxWebhookURL = 2048
+	// WebhookSecretPrefix starts the secrets the server makes up.
+	WebhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 24
+	minWebhookSecret    = 16
+	maxWebhookSecret    = 128
+)
+
+var (
+	ErrInvalidWebhookURL    = errors.New("url must be an absolute http or https URL of at most 2048 characters")
+	ErrInvalidWebhookEvent  = errors.New("events must be task.created, task.updated, task.completed, task.deleted, task.moved or task.restored")
+	ErrInvalidWebhookSecret = fmt.Errorf("secret must be %d to %d characters", minWebhookSecret, maxWebhookSecret)
+)
+
+// Webhook is a subscription of a URL to the task events of a project. Secret
+// is only filled in when the webhook has just been created.
+type Webhook struct {
+	ID        int64         `json:"id" db:"id"`
+	ProjectID int64         `json:"project_id" db:"project_id"`
+	URL       string        `json:"url" db:"url"`
+	Events    WebhookEvents `json:"events" db:"events"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+	Secret    string        `json:"secret,omitempty" db:"-"`
+}
+
+// CreateWebhookRequest subscribes a URL. Events defaults to all of them and
+// Secret to a random one.
+type CreateWebhookRequest struct {
+	URL    string          `json:"url"`
+	Events []EventType     `json:"events"`
+	Secret null.NullString `json:"secret"`
+}
+
+func (r *CreateWebhookRequest) Validate() error {
+	r.URL = strings.TrimSpace(r.URL)
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(r.URL) > maxWebhookURL {
+		return ErrInvalidWebhookURL
+	}
+
+	if len(r.Events) == 0 {
+		r.Events = eventTypes
+	}
+	var events []EventType
+	for _, event := range r.Events {
+		if !event.IsValid() {
+			return ErrInvalidWebhookEvent
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	r.Events = events
+
+	if r.Secret.Valid() {
+		if n := utf8.RuneCountInString(r.Secret.StringValue()); n < minWebhookSecret || n > maxWebhookSecret {
+			return ErrInvalidWebhookSecret
+		}
+	}
+	return nil
+}
+
+// DeliveryStatus is where a webhook delivery stands.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed deliveries ran out of attempts and are in the dead
+	// letter table.
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+func (s *DeliveryStatus) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*s = DeliveryStatus(v)
+	case string:
+		*s = DeliveryStatus(v)
+	default:
+		return fmt.Errorf("cannot scan %T into DeliveryStatus", value)
+	}
+	return nil
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook.
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	WebhookID      int64           `json:"webhook_id" db:"webhook_id"`
+	Event          EventType       `json:"event" db:"event"`
+	Payload        RawJSON         `json:"payload" db:"payload"`
+	Status         DeliveryStatus  `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	ResponseStatus null.NullInt64  `json:"response_status" db:"response_status"`
+	LastError      null.NullString `json:"last_error" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    null.NullTime   `json:"delivered_at" db:"delivered_at"`
+}
+
+// DueDelivery is a pending delivery along with where to send it.
+type DueDelivery struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
+
+const (
+	queryCreateWebhook = `INSERT INTO webhooks (project_id, url, secret, events) VALUES (?, ?, ?, ?)`
+
+	queryGetWebhook = `
+		SELECT id, project_id, url, events, created_at
+		FROM webhooks
+		WHERE id = ? AND project_id = ?
+	`
+
+	queryGetWebhooks = `
+		SELECT id, project_id, url, events, created_at
+		FROM webhooks
+		WHERE project_id = ?
+		ORDER BY id
+	`
+
+	queryDeleteWebhook = `DELETE FROM webhooks WHERE id = ? AND project_id = ?`
+
+	// queryEnqueueWebhooks queues an event for every webhook of the project
+	// that wants it.
+	queryEnqueueWebhooks = `
+	INSERT INTO webhook_deliveries (webhook_id, event, payload)
+	SELECT id, ?, ? FROM webhooks
+	WHERE project_id = ? AND FIND_IN_SET(?, events)
+	`
+
+	queryDeliveryColumns = `
+		d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+		d.response_status, d.last_error, d.created_at, d.delivered_at
+	`
+
+	queryGetDeliveries = `
+		SELECT ` + queryDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?
+	`
+
+	queryGetDelivery = `
+		SELECT ` + queryDeliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.id = ?
+	`
+
+	queryReplayDelivery = `
+	INSERT INTO webhook_deliveries (webhook_id, event, payload)
+	SELECT d.webhook_id, d.event, d.payload
+	FROM webhook_deliveries d
+	INNER JOIN webhooks w ON w.id = d.webhook_id
+	WHERE d.id = ? AND d.webhook_id = ? AND w.project_id = ?
+	`
+
+	queryDueDeliveries = `
+		SELECT ` + queryDeliveryColumns + `, w.url, w.secret
+		FROM webhook_deliveries d
+		INNER JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ?
+		ORDER BY d.next_attempt_at, d.id
+		LIMIT ?
+	`
+
+	// queryClaimDelivery pushes next_attempt_at past the attempt about to be
+	// made, so that no other dispatcher picks the delivery up meanwhile.
+	queryClaimDelivery = `
+	UPDATE webhook_deliveries SET next_attempt_at = ?
+	WHERE id = ? AND status = 'pending' AND next_attempt_at <= ?
+	`
+
+	queryDeliverySucceeded = `
+	UPDATE webhook_deliveries
+	SET status = 'delivered', attempts = ?, response_status = ?, last_error = NULL, delivered_at = NOW()
+	WHERE id = ?
+	`
+
+	queryDeliveryFailed = `
+	UPDATE webhook_deliveries
+	SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, last_error = ?
+	WHERE id = ?
+	`
+
+	queryDeadLetter = `
+	INSERT INTO webhook_dead_letters (delivery_id, webhook_id, event, payload, attempts, response_status, last_error)
+	SELECT id, webhook_id, event, payload, attempts, response_status, last_error
+	FROM webhook_deliveries
+	WHERE id = ?
+	`
+)
+
+// CreateWebhook subscribes a URL to the project's task events and returns
+// the webhook with its secret.
+func CreateWebhook(ctx context.Context, db DBTX, projectID int64, req *CreateWebhookRequest) (*Webhook, error) {
+	secret := req.Secret.StringValue()
+	if !req.Secret.Valid() {
+		random := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		secret = WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(random)
+	}
+
+	result, err := db.ExecContext(ctx, queryCreateWebhook, projectID, req.URL, secret, WebhookEvents(req.Events))
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook Webhook
+	if err := db.GetContext(ctx, &webhook, queryGetWebhook, id, projectID); err != nil {
+		return nil, err
+	}
+	webhook.Secret = secret
+	return &webhook, nil
+}
+
+// GetWebhooks lists the project's webhooks without their secrets.
+func GetWebhooks(ctx context.Context, db DBTX, projectID int64) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	err := db.SelectContext(ctx, &webhooks, queryGetWebhooks, projectID)
+	return webhooks, err
+}
+
+// DeleteWebhook unsubscribes a webhook and drops its deliveries. It returns
+// 0 when the project has no such webhook.
+func DeleteWebhook(ctx context.Context, db DBTX, projectID, webhookID int64) (int64, error) {
+	result, err := db.ExecContext(ctx, queryDeleteWebhook, webhookID, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetWebhookDeliveries lists the latest deliveries of a webhook, newest
+// first, or sql.ErrNoRows when the project has no such webhook.
+func GetWebhookDeliveries(ctx context.Context, db DBTX, projectID, webhookID int64, limit int) ([]WebhookDelivery, error) {
+	var webhook Webhook
+	if err := db.GetContext(ctx, &webhook, queryGetWebhook, webhookID, projectID); err != nil {
+		return nil, err
+	}
+
+	deliveries := []WebhookDelivery{}
+	err := db.SelectContext(ctx, &deliveries, queryGetDeliveries, webhookID, limit)
+	return deliveries, err
+}
+
+// ReplayWebhookDelivery queues the payload of an earlier delivery again, as
+// a new delivery. It returns sql.ErrNoRows when the webhook of the project
+// has no such delivery.
+func ReplayWebhookDelivery(ctx context.Context, db DBTX, projectID, webhookID, deliveryID int64) (*WebhookDelivery, error) {
+	result, err := db.ExecContext(ctx, queryReplayDelivery, deliveryID, webhookID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	if replayed, err := result.RowsAffected(); err != nil || replayed == 0 {
+		if err == nil {
+			err = sql.ErrNoRows
+		}
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery WebhookDelivery
+	if err := db.GetContext(ctx, &delivery, queryGetDelivery, id); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// EnqueueWebhookDeliveries queues an event for every webhook of its project
+// that wants it. The payload of the event is the body of the deliveries.
+func EnqueueWebhookDeliveries(ctx context.Context, db DBTX, event *OutboxEvent) error {
+	_, err := db.ExecContext(ctx, queryEnqueueWebhooks, event.Event, string(event.Payload), event.ProjectID, event.Event)
+	return err
+}
+
+// FindDueDeliveries lists up to limit pending deliveries whose next attempt
+// is due.
+func FindDueDeliveries(ctx context.Context, db DBTX, now time.Time, limit int) ([]DueDelivery, error) {
+	var deliveries []DueDelivery
+	err := db.SelectContext(ctx, &deliveries, queryDueDeliveries, now, limit)
+	return deliveries, err
+}
+
+// ClaimDelivery reserves a due delivery until the given time. It reports
+// false when another dispatcher got to it first.
+func ClaimDelivery(ctx context.Context, db DBTX, deliveryID int64, now, until time.Time) (bool, error) {
+	result, err := db.ExecContext(ctx, queryClaimDelivery, until, deliveryID, now)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	return claimed == 1, err
+}
+
+// DeliveryAttempt is the outcome of sending a delivery once. ResponseStatus
+// is not set when no response came back.
+type DeliveryAttempt struct {
+	Attempts       int
+	ResponseStatus null.NullInt64
+	Error          string
+}
+
+// MarkDelivered marks a delivery as delivered.
+func MarkDelivered(ctx context.Context, db DBTX, deliveryID int64, attempt DeliveryAttempt) error {
+	_, err := db.ExecContext(ctx, queryDeliverySucceeded, attempt.Attempts, attempt.ResponseStatus, deliveryID)
+	return err
+}
+
+// MarkDeliveryFailed records a failed attempt. The delivery is tried again at
+// retryAt, or, when retryAt is not set, moved to the dead letter table.
+func MarkDeliveryFailed(ctx context.Context, db DBTX, deliveryID int64, attempt DeliveryAttempt, retryAt null.NullTime) error {
+	message := attempt.Error
+	if utf8.RuneCountInString(message) > maxErrorMessage {
+		message = string([]rune(message)[:maxErrorMessage])
+	}
+
+	return WithTx(ctx, db, func(tx DBTX) error {
+		status, next := DeliveryPending, retryAt.TimeValue()
+		if !retryAt.Valid() {
+			status, next = DeliveryFailed, time.Now().UTC()
+		}
+		if _, err := tx.ExecContext(ctx, queryDeliveryFailed, status, attempt.Attempts, next, attempt.ResponseStatus, message, deliveryID); err != nil {
+			return err
+		}
+		if status == DeliveryFailed {
+			_, err := tx.ExecContext(ctx, queryDeadLetter, deliveryID)
+			return err
+		}
+		return nil
+	})
+}
@@ -0,0 +1,145 @@
+package model_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/mattn/go-nulltype"
+	mock "github.com/stretchr/testify/mock"
+	"github.com/zeebo/assert"
+)
+
+func TestCreateWebhookRequest_Validate(t *testing.T) {
+	t.Run("defaults to every event", func(t *testing.T) {
+		req := &model.CreateWebhookRequest{URL: " https://example.com/hooks "}
+		assert.NoError(t, req.Validate())
+		assert.Equal(t, "https://example.com/hooks", req.URL)
+		assert.Equal(t, 6, len(req.Events))
+	})
+
+	t.Run("drops repeated events", func(t *testing.T) {
+		req := &model.CreateWebhookRequest{
+			URL:    "http://localhost:8080",
+			Events: []model.EventType{model.EventTaskCompleted, model.EventTaskCompleted},
+		}
+		assert.NoError(t, req.Validate())
+		assert.Equal(t, []model.EventType{model.EventTaskCompleted}, req.Events)
+	})
+
+	for _, tc := range []struct {
+		name string
+		req  model.CreateWebhookRequest
+		err  error
+	}{
+		{"relative url", model.CreateWebhookRequest{URL: "/hooks"}, model.ErrInvalidWebhookURL},
+		{"other scheme", model.CreateWebhookRequest{URL: "ftp://example.com"}, model.ErrInvalidWebhookURL},
+		{"long url", model.CreateWebhookRequest{URL: "https://example.com/" + strings.Repeat("a", 2048)}, model.ErrInvalidWebhookURL},
+		{"unknown event", model.CreateWebhookRequest{URL: "https://example.com", Events: []model.EventType{"task.archived"}}, model.ErrInvalidWebhookEvent},
+		{"short secret", model.CreateWebhookRequest{URL: "https://example.com", Secret: nulltype.NullStringOf("hunter2")}, model.ErrInvalidWebhookSecret},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err, tc.req.Validate())
+		})
+	}
+}
+
+func TestCreateWebhook(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	req := &model.CreateWebhookRequest{
+		URL:    "https://example.com/hooks",
+		Events: []model.EventType{model.EventTaskCreated, model.EventTaskDeleted},
+	}
+
+	var secret string
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			secret = args[2].(string)
+			events, err := args[3].(model.WebhookEvents).Value()
+			assert.NoError(t, err)
+			assert.Equal(t, "task.created,task.deleted", events)
+		}).
+		Return(&mockResult{lastInsertID: 3}, nil)
+	mockDB.EXPECT().
+		GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(3), int64(1)}).
+		Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+			*dest.(*model.Webhook) = model.Webhook{ID: 3, ProjectID: 1, URL: req.URL}
+		}).
+		Return(nil)
+
+	webhook, err := model.CreateWebhook(context.Background(), mockDB, 1, req)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, model.WebhookSecretPrefix))
+	assert.Equal(t, secret, webhook.Secret)
+}
+
+func TestWebhookEvents_Scan(t *testing.T) {
+	var events model.WebhookEvents
+	assert.NoError(t, events.Scan([]byte("task.created,task.completed")))
+	assert.Equal(t, model.WebhookEvents{model.EventTaskCreated, model.EventTaskCompleted}, events)
+}
+
+func TestReplayWebhookDelivery(t *testing.T) {
+	t.Run("queued again", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, []interface{}{int64(7), int64(3), int64(1)}).
+			Return(&mockResult{lastInsertID: 12, rowsAffected: 1}, nil)
+		mockDB.EXPECT().
+			GetContext(mock.Anything, mock.Anything, mock.Anything, []interface{}{int64(12)}).
+			Run(func(ctx context.Context, dest interface{}, query string, args ...interface{}) {
+				*dest.(*model.WebhookDelivery) = model.WebhookDelivery{ID: 12, WebhookID: 3, Status: model.DeliveryPending}
+			}).
+			Return(nil)
+
+		delivery, err := model.ReplayWebhookDelivery(context.Background(), mockDB, 1, 3, 7)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(12), delivery.ID)
+	})
+
+	t.Run("no such delivery", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, mock.Anything).
+			Return(&mockResult{}, nil)
+
+		_, err := model.ReplayWebhookDelivery(context.Background(), mockDB, 1, 3, 7)
+		assert.Equal(t, sql.ErrNoRows, err)
+	})
+}
+
+func TestMarkDeliveryFailed(t *testing.T) {
+	attempt := model.DeliveryAttempt{Attempts: 2, ResponseStatus: nulltype.NullInt64Of(503), Error: "503 Service Unavailable"}
+
+	t.Run("retried", func(t *testing.T) {
+		retryAt := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, []interface{}{model.DeliveryPending, 2, retryAt, attempt.ResponseStatus, attempt.Error, int64(5)}).
+			Return(&mockResult{rowsAffected: 1}, nil).Once()
+
+		err := model.MarkDeliveryFailed(context.Background(), mockDB, 5, attempt, nulltype.NullTimeOf(retryAt))
+		assert.NoError(t, err)
+	})
+
+	t.Run("dead letter", func(t *testing.T) {
+		mockDB := model.NewMockDBTX(t)
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.Anything, mock.MatchedBy(func(args []interface{}) bool {
+				return args[0] == model.DeliveryFailed
+			})).
+			Return(&mockResult{rowsAffected: 1}, nil).Once()
+		mockDB.EXPECT().
+			ExecContext(mock.Anything, mock.MatchedBy(func(query string) bool {
+				return strings.Contains(query, "INSERT INTO webhook_dead_letters")
+			}), []interface{}{int64(5)}).
+			Return(&mockResult{rowsAffected: 1}, nil).Once()
+
+		err := model.MarkDeliveryFailed(context.Background(), mockDB, 5, attempt, nulltype.NullTime{})
+		assert.NoError(t, err)
+	})
+}
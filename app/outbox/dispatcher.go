@@ -0,0 +1,161 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// batchSize caps how many events a run claims; the rest wait for the
+	// next run.
+	batchSize = 100
+	// claimLease is how long claimed events are left to a dispatcher. Events
+	// it has not got to by then are released and go out with a later run.
+	claimLease = 2 * time.Minute
+	// publishTimeout bounds the publishing of a single event to every sink.
+	publishTimeout = 10 * time.Second
+	// retryBase and retryMax bound the wait before an event that failed is
+	// tried again, which doubles after every failure.
+	retryBase = 5 * time.Second
+	retryMax  = 5 * time.Minute
+	// purgeBatch caps how many published events a run deletes.
+	purgeBatch = 1000
+)
+
+// Backoff is the wait before an event is tried again after attempts failed
+// ones.
+func Backoff(attempts int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempts && wait < retryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, retryMax)
+}
+
+// Dispatcher drains the outbox to its sinks. An event is marked published
+// only once every sink has it, and the events of a task go out in the order
+// they were written.
+type Dispatcher struct {
+	db     model.DBTX
+	config model.OutboxConfig
+	sinks  []Sink
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewDispatcher(db model.DBTX, config model.OutboxConfig, sinks []Sink) *Dispatcher {
+	return &Dispatcher{db: db, config: config, sinks: sinks}
+}
+
+// Start drains right away and then every config.Interval, in the background,
+// until Stop is called or ctx is done. An Interval of 0 never drains.
+func (d *Dispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+	go d.run(ctx)
+}
+
+// Stop ends the background draining, waits for the run in progress and
+// closes the sinks.
+func (d *Dispatcher) Stop() {
+	if d.cancel != nil {
+		d.cancel()
+		<-d.done
+	}
+	CloseSinks(d.sinks)
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	if d.config.Interval <= 0 {
+		log.Info("Outbox publishing is turned off")
+		return
+	}
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+	for {
+		if published, err := d.Drain(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Outbox drain failed", zap.Error(err))
+		} else if published > 0 {
+			log.Debug("Outbox events published", zap.Int("count", published))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes the events that are due now and returns how many went
+// out. When a sink fails on an event, the event is tried again later and
+// the events of its task after it wait.
+func (d *Dispatcher) Drain(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	until := now.Add(claimLease)
+	events, err := model.ClaimOutbox(ctx, d.db, now, until, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	failed := map[int64]bool{}
+	var held []int64
+	for i := range events {
+		event := &events[i]
+		// Events after a failed one of their task, or that the lease may not
+		// cover, are handed back as they are.
+		if failed[event.TaskID] || time.Now().Add(publishTimeout).After(until) {
+			failed[event.TaskID] = true
+			held = append(held, event.ID)
+			continue
+		}
+
+		if err := d.publish(ctx, event); err != nil {
+			failed[event.TaskID] = true
+			log.Warn("Failed to publish outbox event",
+				zap.Int64("event_id", event.ID),
+				zap.Int64("task_id", event.TaskID),
+				zap.Int("attempts", event.Attempts+1),
+				zap.Error(err))
+			retryAt := time.Now().UTC().Add(Backoff(event.Attempts + 1))
+			if err := model.MarkOutboxFailed(ctx, d.db, event.ID, err.Error(), retryAt); err != nil {
+				return published, err
+			}
+			continue
+		}
+		if err := model.MarkOutboxPublished(ctx, d.db, event.ID, time.Now().UTC()); err != nil {
+			return published, err
+		}
+		published++
+	}
+	if err := model.RescheduleOutbox(ctx, d.db, held, time.Now().UTC()); err != nil {
+		return published, err
+	}
+
+	if d.config.Retention > 0 {
+		if _, err := model.PurgeOutbox(ctx, d.db, now.Add(-d.config.Retention), purgeBatch); err != nil {
+			return published, err
+		}
+	}
+	return published, nil
+}
+
+func (d *Dispatcher) publish(ctx context.Context, event *model.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+
+	for _, sink := range d.sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			return fmt.Errorf("%s sink: %w", sink.Name(), err)
+		}
+	}
+	return nil
+}
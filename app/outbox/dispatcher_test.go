@@ -0,0 +1,121 @@
+package outbox_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/outbox"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingSink remembers the events it was given and fails on the ones
+// listed in fail.
+type recordingSink struct {
+	published []int64
+	fail      map[int64]bool
+}
+
+func (s *recordingSink) Name() string {
+	return "recording"
+}
+
+func (s *recordingSink) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	if s.fail[event.ID] {
+		return errors.New("disk full")
+	}
+	s.published = append(s.published, event.ID)
+	return nil
+}
+
+// near reports whether v is a time about d from now.
+func near(v interface{}, d time.Duration) bool {
+	at, ok := v.(time.Time)
+	return ok && at.Sub(time.Now().Add(d)).Abs() < 5*time.Second
+}
+
+func expectExec(mockDB *model.MockDBTX, query string, check func(args []interface{}) bool) {
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.MatchedBy(func(q string) bool {
+			return strings.Contains(q, query)
+		}), mock.MatchedBy(check)).
+		Return(driver.RowsAffected(1), nil).Once()
+}
+
+func TestDrain(t *testing.T) {
+	// Event 1 of task 4 fails, so event 2 of the same task waits for it
+	// while event 3 of task 5 goes out.
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]model.OutboxEvent) = []model.OutboxEvent{
+				{ID: 1, TaskID: 4, Attempts: 1}, {ID: 2, TaskID: 4}, {ID: 3, TaskID: 5},
+			}
+			return nil
+		}).Once()
+	expectExec(mockDB, "SET next_attempt_at = ? WHERE id IN (?, ?, ?)", func(args []interface{}) bool {
+		return near(args[0], 2*time.Minute)
+	})
+	expectExec(mockDB, "SET attempts = attempts + 1", func(args []interface{}) bool {
+		// The second failure waits twice as long as the first.
+		return args[0] == "recording sink: disk full" && near(args[1], 10*time.Second) && args[2] == int64(1)
+	})
+	expectExec(mockDB, "SET published_at = ?", func(args []interface{}) bool {
+		return args[1] == int64(3)
+	})
+	expectExec(mockDB, "SET next_attempt_at = ? WHERE id IN (?)", func(args []interface{}) bool {
+		return args[1] == int64(2)
+	})
+
+	sink := &recordingSink{fail: map[int64]bool{1: true}}
+	published, err := outbox.NewDispatcher(mockDB, model.OutboxConfig{Interval: time.Second}, []outbox.Sink{sink}).
+		Drain(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []int64{3}, sink.published)
+}
+
+func TestDrain_Purge(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).Once()
+	expectExec(mockDB, "DELETE FROM outbox", func(args []interface{}) bool {
+		return near(args[0], -time.Hour)
+	})
+
+	config := model.OutboxConfig{Interval: time.Second, Retention: time.Hour}
+	published, err := outbox.NewDispatcher(mockDB, config, nil).Drain(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+}
+
+func TestDispatcherStop(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	// The first run may or may not have started by the time Stop is called.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).Maybe()
+
+	dispatcher := outbox.NewDispatcher(mockDB, model.OutboxConfig{Interval: time.Hour}, nil)
+	dispatcher.Start(context.Background())
+
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
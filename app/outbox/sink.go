@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/bartick/go-task/app/model"
+	"go.uber.org/zap"
+)
+
+// Sink is where published events go. An error leaves the event, and the
+// events of its task after it, to be published again later, so a sink may
+// see an event more than once.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *model.OutboxEvent) error
+}
+
+// NewSinks builds the sinks named by config.Sinks.
+func NewSinks(db model.DBTX, config model.OutboxConfig) ([]Sink, error) {
+	var sinks []Sink
+	for _, name := range config.Sinks {
+		switch name {
+		case "webhook":
+			sinks = append(sinks, &WebhookSink{DB: db})
+		case "file":
+			sink, err := NewFileSink(config.File)
+			if err != nil {
+				CloseSinks(sinks)
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "stdout":
+			sinks = append(sinks, NewWriterSink("stdout", os.Stdout))
+		default:
+			CloseSinks(sinks)
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// CloseSinks closes the sinks that hold on to a file.
+func CloseSinks(sinks []Sink) {
+	for _, sink := range sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				log.Warn("Failed to close outbox sink", zap.String("sink", sink.Name()), zap.Error(err))
+			}
+		}
+	}
+}
+
+// WebhookSink queues events for the webhooks of their project, which the
+// webhook dispatcher then delivers.
+type WebhookSink struct {
+	DB model.DBTX
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhook"
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	return model.EnqueueWebhookDeliveries(ctx, s.DB, event)
+}
+
+// WriterSink writes events as newline delimited JSON, one object a line.
+type WriterSink struct {
+	name string
+	file *os.File
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(name string, w io.Writer) *WriterSink {
+	return &WriterSink{name: name, w: w}
+}
+
+// NewFileSink appends events to the file at path, creating it if needed.
+// Every event is synced to disk before it counts as published.
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox file: %w", err)
+	}
+	return &WriterSink{name: "file", file: file, w: file}, nil
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if s.file != nil {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// Close closes the file of a file sink.
+func (s *WriterSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,47 @@
+package outbox_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/outbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.ndjson")
+	sink, err := outbox.NewFileSink(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	createdAt := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	for _, event := range []model.OutboxEvent{
+		{ID: 1, ProjectID: 1, TaskID: 4, Event: model.EventTaskCreated, Payload: model.RawJSON(`{"task_id":4}`), CreatedAt: createdAt},
+		{ID: 2, ProjectID: 1, TaskID: 4, Event: model.EventTaskDeleted, Payload: model.RawJSON(`{"task_id":4}`), CreatedAt: createdAt},
+	} {
+		assert.NoError(t, sink.Publish(context.Background(), &event))
+	}
+	assert.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{"id":1,"project_id":1,"task_id":4,"event":"task.created","payload":{"task_id":4},"created_at":"2026-03-02T15:00:00Z"}`, lines[0])
+}
+
+func TestNewSinks(t *testing.T) {
+	_, err := outbox.NewSinks(model.NewMockDBTX(t), model.OutboxConfig{Sinks: []string{"webhook", "kafka"}})
+	assert.Error(t, err)
+
+	sinks, err := outbox.NewSinks(model.NewMockDBTX(t), model.OutboxConfig{Sinks: []string{"webhook", "stdout"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "webhook", sinks[0].Name())
+	assert.Equal(t, "stdout", sinks[1].Name())
+}
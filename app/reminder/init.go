@@ -0,0 +1,7 @@
+package reminder
+
+import "github.com/bartick/go-task/app/shared/utils"
+
+var (
+	log = utils.InitLogger()
+)
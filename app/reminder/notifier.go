@@ -0,0 +1,114 @@
+package reminder
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"go.uber.org/zap"
+)
+
+// Notifier delivers reminders. An error leaves the reminder to be sent again
+// later.
+type Notifier interface {
+	Notify(ctx context.Context, reminder *model.Reminder) error
+}
+
+// NewNotifier builds the notifier named by config.Notifier.
+func NewNotifier(config model.ReminderConfig, logger *zap.Logger) Notifier {
+	if config.Notifier == "smtp" {
+		return &SMTPNotifier{Addr: config.SMTPAddr, From: config.SMTPFrom}
+	}
+	return &LogNotifier{Log: logger}
+}
+
+// LogNotifier writes reminders to the log.
+type LogNotifier struct {
+	Log *zap.Logger
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder *model.Reminder) error {
+	n.Log.Info("Task reminder",
+		zap.Int64("project_id", reminder.ProjectID),
+		zap.Int64("task_id", reminder.TaskID),
+		zap.String("title", reminder.Title),
+		zap.String("due_date", reminder.DueDate.Format(time.DateOnly)),
+		zap.String("reminder", reminder.Name),
+		zap.String("assignee_email", reminder.AssigneeEmail.StringValue()))
+	return nil
+}
+
+// SMTPNotifier mails reminders to the assignee of the task through a relay
+// that needs no authentication. Tasks without an assignee are skipped.
+type SMTPNotifier struct {
+	Addr string
+	From string
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder *model.Reminder) error {
+	if !reminder.AssigneeEmail.Valid() {
+		return nil
+	}
+	to := reminder.AssigneeEmail.StringValue()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	host, _, _ := net.SplitHostPort(n.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(n.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(reminderMail(n.From, to, reminder)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func reminderMail(from, to string, reminder *model.Reminder) []byte {
+	due := reminder.DueDate.Format(time.DateOnly)
+	subject := fmt.Sprintf("Reminder: %s is due on %s", reminder.Title, due)
+	body := fmt.Sprintf("Task #%d, %q, is due on %s.", reminder.TaskID, reminder.Title, due)
+	if reminder.Overdue() {
+		subject = fmt.Sprintf("Overdue: %s was due on %s", reminder.Title, due)
+		body = fmt.Sprintf("Task #%d, %q, was due on %s and is not done yet.", reminder.TaskID, reminder.Title, due)
+	}
+	// Titles come from users; keep them from adding headers, and encode
+	// anything that is not ASCII.
+	subject = mime.QEncoding.Encode("UTF-8", strings.NewReplacer("\r", " ", "\n", " ").Replace(subject))
+
+	return []byte("From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		body + "\r\n")
+}
@@ -0,0 +1,94 @@
+package reminder_test
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/reminder"
+	"github.com/mattn/go-nulltype"
+	"github.com/stretchr/testify/assert"
+)
+
+// smtpStandIn accepts one mail on a local port and hands back the envelope
+// and the message.
+func smtpStandIn(t *testing.T) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		var lines []string
+		_ = text.PrintfLine("220 stand-in ready")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch command := strings.ToUpper(strings.Fields(line + " ")[0]); command {
+			case "EHLO", "HELO":
+				_ = text.PrintfLine("250 stand-in")
+			case "MAIL", "RCPT":
+				lines = append(lines, line)
+				_ = text.PrintfLine("250 OK")
+			case "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				body, _ := text.ReadDotLines()
+				lines = append(lines, body...)
+				_ = text.PrintfLine("250 queued")
+			case "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- lines
+				return
+			default:
+				_ = text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, received := smtpStandIn(t)
+	notifier := &reminder.SMTPNotifier{Addr: addr, From: "go-task@localhost"}
+
+	err := notifier.Notify(context.Background(), &model.Reminder{
+		TaskID:        3,
+		Title:         "Corriger bug login",
+		DueDate:       time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC),
+		Name:          model.ReminderOverdue,
+		AssigneeEmail: nulltype.NullStringOf("ada@example.com"),
+	})
+	assert.NoError(t, err)
+
+	select {
+	case lines := <-received:
+		mail := strings.Join(lines, "\n")
+		assert.Contains(t, mail, "MAIL FROM:<go-task@localhost>")
+		assert.Contains(t, mail, "RCPT TO:<ada@example.com>")
+		assert.Contains(t, mail, "Subject: Overdue: Corriger bug login was due on 2025-08-15")
+		assert.Contains(t, mail, "was due on 2025-08-15 and is not done yet.")
+	case <-time.After(time.Second):
+		t.Fatal("no mail received")
+	}
+}
+
+func TestSMTPNotifier_NoAssignee(t *testing.T) {
+	// Nothing listens there, so any attempt to send would fail.
+	notifier := &reminder.SMTPNotifier{Addr: "127.0.0.1:1", From: "go-task@localhost"}
+	assert.NoError(t, notifier.Notify(context.Background(), &model.Reminder{TaskID: 3}))
+}
@@ -0,0 +1,117 @@
+package reminder
+
+import (
+	"context"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"go.uber.org/zap"
+)
+
+const (
+	// batchSize caps how many reminders of one kind a scan sends; the rest
+	// wait for the next scan.
+	batchSize = 100
+	// notifyTimeout bounds the delivery of a single reminder.
+	notifyTimeout = 10 * time.Second
+)
+
+// Scheduler scans the tasks for reminders that are due and hands each one to
+// a Notifier exactly once.
+type Scheduler struct {
+	db       model.DBTX
+	config   model.ReminderConfig
+	notifier Notifier
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewScheduler(db model.DBTX, config model.ReminderConfig, notifier Notifier) *Scheduler {
+	return &Scheduler{db: db, config: config, notifier: notifier}
+}
+
+// Start scans right away and then every config.Interval, in the background,
+// until Stop is called or ctx is done. An Interval of 0 never scans.
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.run(ctx)
+}
+
+// Stop ends the background scan and waits for the one in progress.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	<-s.done
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	if s.config.Interval <= 0 {
+		log.Info("Reminders are turned off")
+		return
+	}
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		if sent, err := s.Scan(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Reminder scan failed", zap.Error(err))
+		} else if sent > 0 {
+			log.Info("Reminders sent", zap.Int("count", sent))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan sends the reminders that are due now, ahead of the due dates first,
+// then the overdue ones, and returns how many went out. A reminder the
+// notifier fails on is tried again on the next scan.
+func (s *Scheduler) Scan(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	leads := append(append([]time.Duration{}, s.config.LeadTimes...), 0)
+
+	sent := 0
+	for _, lead := range leads {
+		reminders, err := model.FindDueReminders(ctx, s.db, lead, now, batchSize)
+		if err != nil {
+			return sent, err
+		}
+		for i := range reminders {
+			ok, err := s.deliver(ctx, &reminders[i])
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+	}
+	return sent, nil
+}
+
+func (s *Scheduler) deliver(ctx context.Context, reminder *model.Reminder) (bool, error) {
+	claimed, err := model.ClaimReminder(ctx, s.db, reminder)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	notifyCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	if err := s.notifier.Notify(notifyCtx, reminder); err != nil {
+		log.Warn("Failed to send reminder",
+			zap.Int64("task_id", reminder.TaskID),
+			zap.String("reminder", reminder.Name),
+			zap.Error(err))
+		return false, model.ReleaseReminder(ctx, s.db, reminder)
+	}
+	return true, nil
+}
@@ -0,0 +1,95 @@
+package reminder_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/reminder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordingNotifier remembers the reminders it was given and fails on the
+// tasks listed in fail.
+type recordingNotifier struct {
+	sent []int64
+	fail map[int64]bool
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, r *model.Reminder) error {
+	if n.fail[r.TaskID] {
+		return errors.New("mailbox unavailable")
+	}
+	n.sent = append(n.sent, r.TaskID)
+	return nil
+}
+
+func expectReminders(mockDB *model.MockDBTX, name string, reminders ...model.Reminder) {
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(args []interface{}) bool {
+			return args[0] == name
+		})).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]model.Reminder) = reminders
+			return nil
+		}).Once()
+}
+
+func expectClaim(mockDB *model.MockDBTX, taskID int64, name string, rows driver.RowsAffected) {
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.MatchedBy(func(args []interface{}) bool {
+			return args[0] == taskID && args[2] == name
+		})).
+		Return(rows, nil).Once()
+}
+
+func TestSchedulerScan(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	config := model.ReminderConfig{Interval: time.Minute, LeadTimes: []time.Duration{24 * time.Hour}}
+
+	// Task 4 is due tomorrow. Task 3 was already reminded by another
+	// scheduler, and the mail for task 7 bounces, so its claim is released.
+	expectReminders(mockDB, "24h0m0s", model.Reminder{TaskID: 4})
+	expectClaim(mockDB, 4, "24h0m0s", 1)
+	expectReminders(mockDB, model.ReminderOverdue, model.Reminder{TaskID: 3}, model.Reminder{TaskID: 7})
+	expectClaim(mockDB, 3, model.ReminderOverdue, 0)
+	expectClaim(mockDB, 7, model.ReminderOverdue, 1)
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.Anything, mock.MatchedBy(func(args []interface{}) bool {
+			return args[0] == int64(7)
+		})).
+		Return(driver.RowsAffected(1), nil).Once()
+
+	notifier := &recordingNotifier{fail: map[int64]bool{7: true}}
+	sent, err := reminder.NewScheduler(mockDB, config, notifier).Scan(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []int64{4}, notifier.sent)
+}
+
+func TestSchedulerStop(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	// The first scan may or may not have started by the time Stop is called.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).Maybe()
+
+	scheduler := reminder.NewScheduler(mockDB, model.ReminderConfig{Interval: time.Hour}, &recordingNotifier{})
+	scheduler.Start(context.Background())
+
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
@@ -11,13 +11,60 @@ const (
 	// Ping
 	pathPing = "/ping"
 
+	// Projects. Tasks, categories and the trash live under a project, and
+	// the paths below are relative to pathProjectsID.
+	pathProjects   = "/projects"
+	pathProjectsID = "/projects/:pid"
+	pathMembers    = "/members"
+	pathMember     = "/members/:user_id"
+
 	// Tasks
 	pathTasks    = "/tasks"
 	pathTasksID  = "/tasks/:id"
 	pathSubTasks = "/tasks/:id/subtasks"
+	pathMoveTask = "/tasks/:id/move"
+	pathHistory  = "/tasks/:id/history"
+
+	// Dependencies. A blocker is a task the task waits on.
+	pathDependencies = "/tasks/:id/dependencies"
+	pathBlocker      = "/tasks/:id/blockers/:blocker_id"
+
+	// Schedule
+	pathSchedule     = "/schedule"
+	pathTaskSchedule = "/tasks/:id/schedule"
+
+	// Recurrence
+	pathOccurrences = "/tasks/:id/occurrences"
+
+	// Categories
+	pathCategories   = "/categories"
+	pathCategoriesID = "/categories/:id"
+	pathPermissions  = "/categories/:id/permissions"
+	pathPermission   = "/categories/:id/permissions/:user_id"
+
+	// Users
+	pathUsers   = "/users"
+	pathUsersID = "/users/:id"
+
+	// API keys
+	pathAPIKeys   = "/api-keys"
+	pathAPIKeysID = "/api-keys/:id"
+
+	// Trash
+	pathTrash       = "/trash"
+	pathRestoreTask = "/tasks/:id/restore"
+
+	// Event stream
+	pathEvents = "/events"
+
+	// Webhooks
+	pathWebhooks   = "/webhooks"
+	pathWebhooksID = "/webhooks/:id"
+	pathDeliveries = "/webhooks/:id/deliveries"
+	pathReplay     = "/webhooks/:id/deliveries/:delivery_id/replay"
 )
 
-func AddAPIRouter(db model.DBTX) *gin.Engine {
+func AddAPIRouter(db model.DBTX, config *model.Configuration) *gin.Engine {
 	router := gin.New()
 	router.Use(middleware.LogRequest(log))
 	router.Use(middleware.CORSMiddleware())
@@ -25,15 +72,85 @@ func AddAPIRouter(db model.DBTX) *gin.Engine {
 	// Ping
 	router.GET(pathPing, handler.HandlerPing)
 
-	router.Use(middleware.Config(db))
+	router.Use(middleware.Config(db, config))
+	// The event stream stays open, so it is the one route the query timeout
+	// does not cover; it applies the timeout to each read itself.
+	router.GET(pathProjectsID+pathEvents, middleware.Auth(config.Auth), middleware.Project(), handler.HandlerStreamEvents)
+	router.Use(middleware.QueryTimeout(config.Database.QueryTimeout))
+	// Everything below needs a bearer token. Reading is open to every role;
+	// changes are checked per route.
+	router.Use(middleware.Auth(config.Auth))
+	edit := middleware.AuthorizeTask(model.AccessEditTask)
+	admin := middleware.RequireRole(model.RoleAdmin)
+
+	// Projects
+	router.GET(pathProjects, handler.HandlerGetProjects)
+	router.POST(pathProjects, admin, handler.HandlerCreateProject)
+	router.PATCH(pathProjectsID, admin, handler.HandlerRenameProject)
+	router.DELETE(pathProjectsID, admin, handler.HandlerDeleteProject)
+
+	// Only admins and the project's members get past Project.
+	project := router.Group(pathProjectsID, middleware.Project())
+	project.GET("", handler.HandlerGetProject)
+	project.GET(pathMembers, admin, handler.HandlerGetProjectMembers)
+	project.PUT(pathMember, admin, handler.HandlerAddProjectMember)
+	project.DELETE(pathMember, admin, handler.HandlerRemoveProjectMember)
 
 	// Tasks
-	router.GET(pathTasks, handler.HandlerGetTasks)
-	router.GET(pathSubTasks, handler.HandlerGetSubTasks)
-	router.POST(pathTasks, handler.HandlerCreateTasks)
-	router.GET(pathTasksID, handler.HandlerGetTask)
-	router.PATCH(pathTasksID, handler.HandlerUpdateTask)
-	router.DELETE(pathTasksID, handler.HandlerDeleteTask)
+	project.GET(pathTasks, handler.HandlerGetTasks)
+	project.GET(pathSubTasks, handler.HandlerGetSubTasks)
+	project.POST(pathTasks, handler.HandlerCreateTasks)
+	project.GET(pathTasksID, handler.HandlerGetTask)
+	project.PATCH(pathTasksID, edit, handler.HandlerUpdateTask)
+	project.DELETE(pathTasksID, middleware.AuthorizeTask(model.AccessDeleteTask), handler.HandlerDeleteTask)
+	project.POST(pathMoveTask, edit, handler.HandlerMoveTask)
+	project.GET(pathHistory, handler.HandlerGetTaskHistory)
+
+	// Dependencies
+	project.GET(pathDependencies, handler.HandlerGetTaskDependencies)
+	project.PUT(pathBlocker, edit, handler.HandlerAddTaskBlocker)
+	project.DELETE(pathBlocker, edit, handler.HandlerRemoveTaskBlocker)
+
+	// Schedule
+	project.GET(pathSchedule, handler.HandlerGetSchedule)
+	project.GET(pathTaskSchedule, handler.HandlerGetTaskSchedule)
+
+	// Recurrence
+	project.GET(pathOccurrences, handler.HandlerGetTaskOccurrences)
+
+	// Categories
+	project.GET(pathCategories, handler.HandlerGetCategories)
+	project.POST(pathCategories, admin, handler.HandlerCreateCategory)
+	project.GET(pathCategoriesID, handler.HandlerGetCategory)
+	project.PATCH(pathCategoriesID, admin, handler.HandlerRenameCategory)
+	project.DELETE(pathCategoriesID, admin, handler.HandlerDeleteCategory)
+	project.GET(pathPermissions, admin, handler.HandlerGetCategoryPermissions)
+	project.PUT(pathPermission, admin, handler.HandlerSetCategoryPermission)
+	project.DELETE(pathPermission, admin, handler.HandlerDeleteCategoryPermission)
+
+	// Users
+	router.GET(pathUsers, handler.HandlerGetUsers)
+	router.POST(pathUsers, admin, handler.HandlerCreateUser)
+	router.GET(pathUsersID, handler.HandlerGetUser)
+	router.PATCH(pathUsersID, admin, handler.HandlerUpdateUser)
+	router.DELETE(pathUsersID, admin, handler.HandlerDeleteUser)
+
+	// API keys
+	router.GET(pathAPIKeys, handler.HandlerGetAPIKeys)
+	router.POST(pathAPIKeys, handler.HandlerCreateAPIKey)
+	router.DELETE(pathAPIKeysID, handler.HandlerRevokeAPIKey)
+
+	// Trash
+	project.GET(pathTrash, handler.HandlerGetTrash)
+	project.DELETE(pathTrash, admin, handler.HandlerPurgeTrash)
+	project.POST(pathRestoreTask, edit, handler.HandlerRestoreTask)
+
+	// Webhooks
+	project.GET(pathWebhooks, admin, handler.HandlerGetWebhooks)
+	project.POST(pathWebhooks, admin, handler.HandlerCreateWebhook)
+	project.DELETE(pathWebhooksID, admin, handler.HandlerDeleteWebhook)
+	project.GET(pathDeliveries, admin, handler.HandlerGetWebhookDeliveries)
+	project.POST(pathReplay, admin, handler.HandlerReplayWebhookDelivery)
 
 	return router
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Auth requires a bearer token, an API key or a JWT, on every request that
+// reaches it and stores the resolved *model.Principal as "principal". It
+// needs the database set by Config.
+func Auth(config model.AuthConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		db := c.MustGet("db").(model.DBTX)
+		principal, err := model.Authenticate(c.Request.Context(), db, config, strings.TrimSpace(token))
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+
+		c.Set("principal", principal)
+		c.Next()
+	}
+}
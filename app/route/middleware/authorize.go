@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets through principals whose own role includes role. It
+// goes after Auth.
+func RequireRole(role model.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := principal(c)
+		if !ok {
+			return
+		}
+		if err := model.RequireRole(principal, role); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// AuthorizeTask applies the task policy to the task in the :id parameter,
+// within the project set by Project. It goes after Auth and Project; an
+// invalid id is left for the handler to reject.
+func AuthorizeTask(access model.TaskAccess) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := principal(c)
+		if !ok {
+			return
+		}
+		taskID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		db := c.MustGet("db").(model.DBTX)
+		if err := model.AuthorizeTask(c.Request.Context(), db, principal, access, c.GetInt64("project_id"), taskID); err != nil {
+			abortWithError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// principal returns the principal stored by Auth, answering 401 when there
+// is none.
+func principal(c *gin.Context) (*model.Principal, bool) {
+	if value, ok := c.Get("principal"); ok {
+		if principal, ok := value.(*model.Principal); ok {
+			return principal, true
+		}
+	}
+	abortWithError(c, model.ErrMissingCredentials)
+	return nil, false
+}
+
+// abortWithError answers a request that failed authentication or
+// authorization.
+func abortWithError(c *gin.Context, err error) {
+	var forbidden *model.ForbiddenError
+	switch {
+	case err == model.ErrMissingCredentials || err == model.ErrInvalidCredentials:
+		c.Header("WWW-Authenticate", `Bearer realm="go-task"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.As(err, &forbidden):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": forbidden.Reason})
+	case errors.Is(err, context.DeadlineExceeded):
+		c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Database query timed out"})
+	default:
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to authorize request"})
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Config(db model.DBTX) gin.HandlerFunc {
+func Config(db model.DBTX, config *model.Configuration) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Set("db", db)
+		c.Set("config", config)
 		c.Next()
 	}
 }
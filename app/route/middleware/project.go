@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/gin-gonic/gin"
+)
+
+// Project loads the project in the :pid parameter and stores its id as
+// "project_id", which the task and category handlers scope every query to.
+// It goes after Auth and lets in admins and the project's members. It
+// answers 400 for a malformed id and 404 for an unknown project or one the
+// principal is not a member of.
+func Project() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := principal(c)
+		if !ok {
+			return
+		}
+		projectID, err := strconv.ParseInt(c.Param("pid"), 10, 64)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+			return
+		}
+
+		db := c.MustGet("db").(model.DBTX)
+		if _, err := model.GetMemberProject(c.Request.Context(), db, principal, projectID); err != nil {
+			switch {
+			case err == sql.ErrNoRows:
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			case errors.Is(err, context.DeadlineExceeded):
+				c.AbortWithStatusJSON(http.StatusGatewayTimeout, gin.H{"error": "Database query timed out"})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve project"})
+			}
+			return
+		}
+
+		c.Set("project_id", projectID)
+		c.Next()
+	}
+}
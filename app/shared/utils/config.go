@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bartick/go-task/app/model"
@@ -14,6 +17,49 @@ func LoadConfig() (*model.Configuration, error) {
 		log.Error("No .env file found, reading from environment")
 	}
 
+	maxDepth, err := strconv.Atoi(getEnv("TASK_MAX_DEPTH", "0"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TASK_MAX_DEPTH: %w", err)
+	}
+
+	trashRetention, err := time.ParseDuration(getEnv("TRASH_RETENTION", "720h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRASH_RETENTION: %w", err)
+	}
+
+	queryTimeout, err := time.ParseDuration(getEnv("DB_QUERY_TIMEOUT", "10s"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DB_QUERY_TIMEOUT: %w", err)
+	}
+
+	reminder, err := loadReminderConfig()
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := loadWebhookConfig()
+	if err != nil {
+		return nil, err
+	}
+	outbox, err := loadOutboxConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	auth := model.AuthConfig{
+		JWTSecret:   []byte(getEnv("JWT_HS256_SECRET", "")),
+		JWTIssuer:   getEnv("JWT_ISSUER", ""),
+		JWTAudience: getEnv("JWT_AUDIENCE", ""),
+	}
+	if path := getEnv("JWT_RS256_PUBLIC_KEY_FILE", ""); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT_RS256_PUBLIC_KEY_FILE: %w", err)
+		}
+		if auth.JWTPublicKey, err = model.ParseRSAPublicKey(data); err != nil {
+			return nil, fmt.Errorf("invalid JWT_RS256_PUBLIC_KEY_FILE: %w", err)
+		}
+	}
+
 	config := &model.Configuration{
 		Application: model.ApplicationConfig{
 			LogLevel: getEnv("LOG_LEVEL", "info"),
@@ -29,13 +75,105 @@ func LoadConfig() (*model.Configuration, error) {
 			DBHost: getEnv("DB_HOST", "localhost"),
 			DBPort: getEnv("DB_PORT", "4000"),
 			DBName: getEnv("DB_NAME", "tasking"),
+
+			QueryTimeout: queryTimeout,
+		},
+		Task: model.TaskConfig{
+			MaxDepth:       maxDepth,
+			TrashRetention: trashRetention,
 		},
+		Auth:     auth,
+		Reminder: reminder,
+		Webhook:  webhook,
+		Outbox:   outbox,
 	}
 
 	return config, nil
 
 }
 
+func loadReminderConfig() (model.ReminderConfig, error) {
+	interval, err := time.ParseDuration(getEnv("REMINDER_INTERVAL", "1m"))
+	if err != nil || interval < 0 {
+		return model.ReminderConfig{}, fmt.Errorf("invalid REMINDER_INTERVAL %q", getEnv("REMINDER_INTERVAL", ""))
+	}
+
+	var leadTimes []time.Duration
+	for _, raw := range strings.Split(getEnv("REMINDER_LEAD_TIMES", "24h"), ",") {
+		if raw = strings.TrimSpace(raw); raw == "" {
+			continue
+		}
+		lead, err := time.ParseDuration(raw)
+		if err != nil || lead <= 0 {
+			return model.ReminderConfig{}, fmt.Errorf("invalid REMINDER_LEAD_TIMES entry %q", raw)
+		}
+		leadTimes = append(leadTimes, lead)
+	}
+
+	notifier := getEnv("REMINDER_NOTIFIER", "log")
+	if notifier != "log" && notifier != "smtp" {
+		return model.ReminderConfig{}, fmt.Errorf("invalid REMINDER_NOTIFIER %q, want log or smtp", notifier)
+	}
+
+	return model.ReminderConfig{
+		Interval:  interval,
+		LeadTimes: leadTimes,
+		Notifier:  notifier,
+		SMTPAddr:  getEnv("SMTP_ADDR", "localhost:25"),
+		SMTPFrom:  getEnv("SMTP_FROM", "go-task@localhost"),
+	}, nil
+}
+
+func loadWebhookConfig() (model.WebhookConfig, error) {
+	interval, err := time.ParseDuration(getEnv("WEBHOOK_INTERVAL", "5s"))
+	if err != nil || interval < 0 {
+		return model.WebhookConfig{}, fmt.Errorf("invalid WEBHOOK_INTERVAL %q", getEnv("WEBHOOK_INTERVAL", ""))
+	}
+	maxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || maxAttempts < 1 {
+		return model.WebhookConfig{}, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS %q", getEnv("WEBHOOK_MAX_ATTEMPTS", ""))
+	}
+	timeout, err := time.ParseDuration(getEnv("WEBHOOK_TIMEOUT", "10s"))
+	if err != nil || timeout <= 0 {
+		return model.WebhookConfig{}, fmt.Errorf("invalid WEBHOOK_TIMEOUT %q", getEnv("WEBHOOK_TIMEOUT", ""))
+	}
+
+	return model.WebhookConfig{
+		Interval:    interval,
+		MaxAttempts: maxAttempts,
+		Timeout:     timeout,
+	}, nil
+}
+
+func loadOutboxConfig() (model.OutboxConfig, error) {
+	interval, err := time.ParseDuration(getEnv("OUTBOX_INTERVAL", "1s"))
+	if err != nil || interval < 0 {
+		return model.OutboxConfig{}, fmt.Errorf("invalid OUTBOX_INTERVAL %q", getEnv("OUTBOX_INTERVAL", ""))
+	}
+	retention, err := time.ParseDuration(getEnv("OUTBOX_RETENTION", "168h"))
+	if err != nil || retention < 0 {
+		return model.OutboxConfig{}, fmt.Errorf("invalid OUTBOX_RETENTION %q", getEnv("OUTBOX_RETENTION", ""))
+	}
+
+	var sinks []string
+	for _, sink := range strings.Split(getEnv("OUTBOX_SINKS", "webhook"), ",") {
+		if sink = strings.TrimSpace(sink); sink == "" {
+			continue
+		}
+		if sink != "webhook" && sink != "file" && sink != "stdout" {
+			return model.OutboxConfig{}, fmt.Errorf("invalid OUTBOX_SINKS entry %q, want webhook, file or stdout", sink)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return model.OutboxConfig{
+		Interval:  interval,
+		Sinks:     sinks,
+		File:      getEnv("OUTBOX_FILE", "outbox.ndjson"),
+		Retention: retention,
+	}, nil
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	null "github.com/mattn/go-nulltype"
+	"go.uber.org/zap"
+)
+
+const (
+	// batchSize caps how many deliveries a run sends; the rest wait for the
+	// next run.
+	batchSize = 100
+	// leaseMargin is added to the attempt timeout while a delivery is
+	// claimed, so that one left behind by a crashed dispatcher is picked up
+	// again later.
+	leaseMargin = time.Minute
+	// retryBase and retryMax bound the wait before the next attempt, which
+	// doubles after every failure.
+	retryBase = 30 * time.Second
+	retryMax  = 6 * time.Hour
+	// maxResponseBody is how much of a response is read before the
+	// connection is let go.
+	maxResponseBody = 64 << 10
+)
+
+// Backoff is the wait before attempt number attempts+1, after attempts
+// failed ones.
+func Backoff(attempts int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempts && wait < retryMax; i++ {
+		wait *= 2
+	}
+	return min(wait, retryMax)
+}
+
+// Dispatcher sends the queued webhook deliveries, retrying the ones that
+// fail until they run out of attempts.
+type Dispatcher struct {
+	db     model.DBTX
+	config model.WebhookConfig
+	client *http.Client
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewDispatcher(db model.DBTX, config model.WebhookConfig) *Dispatcher {
+	return &Dispatcher{db: db, config: config, client: &http.Client{Timeout: config.Timeout}}
+}
+
+// Start sends right away and then every config.Interval, in the background,
+// until Stop is called or ctx is done. An Interval of 0 never sends.
+func (d *Dispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+	go d.run(ctx)
+}
+
+// Stop ends the background delivery and waits for the run in progress.
+func (d *Dispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	if d.config.Interval <= 0 {
+		log.Info("Webhook delivery is turned off")
+		return
+	}
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+	for {
+		if sent, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Error("Webhook delivery failed", zap.Error(err))
+		} else if sent > 0 {
+			log.Info("Webhooks delivered", zap.Int("count", sent))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch sends the deliveries that are due now and returns how many were
+// accepted. A delivery that fails is tried again later, or moved to the dead
+// letter table once it has been tried config.MaxAttempts times.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	deliveries, err := model.FindDueDeliveries(ctx, d.db, now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range deliveries {
+		ok, err := d.deliver(ctx, &deliveries[i])
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *model.DueDelivery) (bool, error) {
+	// The lease runs from the claim, not from the start of the batch, which
+	// may be long gone after slow deliveries.
+	now := time.Now().UTC()
+	claimed, err := model.ClaimDelivery(ctx, d.db, delivery.ID, now, now.Add(d.config.Timeout+leaseMargin))
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	attempt := d.send(ctx, delivery)
+	if attempt.Error == "" {
+		return true, model.MarkDelivered(ctx, d.db, delivery.ID, attempt)
+	}
+
+	var retryAt null.NullTime
+	if attempt.Attempts < d.config.MaxAttempts {
+		retryAt = null.NullTimeOf(time.Now().UTC().Add(Backoff(attempt.Attempts)))
+	}
+	log.Warn("Failed to deliver webhook",
+		zap.Int64("webhook_id", delivery.WebhookID),
+		zap.Int64("delivery_id", delivery.ID),
+		zap.Int("attempts", attempt.Attempts),
+		zap.Bool("dead_letter", !retryAt.Valid()),
+		zap.String("error", attempt.Error))
+	return false, model.MarkDeliveryFailed(ctx, d.db, delivery.ID, attempt, retryAt)
+}
+
+// send makes one attempt. Any 2xx response is a success.
+func (d *Dispatcher) send(ctx context.Context, delivery *model.DueDelivery) model.DeliveryAttempt {
+	attempt := model.DeliveryAttempt{Attempts: delivery.Attempts + 1}
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-task-webhooks")
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set("X-Webhook-Signature", Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	attempt.ResponseStatus = null.NullInt64Of(int64(resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = resp.Status
+	}
+	return attempt
+}
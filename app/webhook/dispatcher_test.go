@@ -0,0 +1,200 @@
+package webhook_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bartick/go-task/app/model"
+	"github.com/bartick/go-task/app/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const secret = "whsec_test-secret"
+
+// receiver accepts the deliveries whose id is not in fail, after checking
+// their signature, and takes delay to answer.
+type receiver struct {
+	t     *testing.T
+	fail  map[string]bool
+	delay time.Duration
+
+	mu       sync.Mutex
+	accepted []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	assert.NoError(rc.t, err)
+	unix, err := strconv.ParseInt(r.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	assert.NoError(rc.t, err)
+	assert.True(rc.t, webhook.Verify(secret, time.Unix(unix, 0), body, r.Header.Get("X-Webhook-Signature")))
+	assert.Equal(rc.t, "task.completed", r.Header.Get("X-Webhook-Event"))
+
+	time.Sleep(rc.delay)
+	id := r.Header.Get("X-Webhook-Delivery")
+	if rc.fail[id] {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	rc.mu.Lock()
+	rc.accepted = append(rc.accepted, id)
+	rc.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func due(url string, id int64, attempts int) model.DueDelivery {
+	return model.DueDelivery{
+		WebhookDelivery: model.WebhookDelivery{
+			ID:       id,
+			Event:    model.EventTaskCompleted,
+			Payload:  model.RawJSON(`{"event":"task.completed","task_id":4}`),
+			Attempts: attempts,
+		},
+		URL:    url,
+		Secret: secret,
+	}
+}
+
+// expectExec expects one statement, told apart by the argument at index
+// pos.
+func expectExec(mockDB *model.MockDBTX, query string, pos int, value interface{}) *model.MockDBTX_ExecContext_Call {
+	call := mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.MatchedBy(func(q string) bool {
+			return strings.Contains(q, query)
+		}), mock.MatchedBy(func(args []interface{}) bool {
+			return len(args) > pos && args[pos] == value
+		}))
+	call.Return(driver.RowsAffected(1), nil).Once()
+	return call
+}
+
+func TestDispatch(t *testing.T) {
+	rc := &receiver{t: t, fail: map[string]bool{"2": true, "3": true}}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	// Delivery 1 is accepted. Delivery 2 fails and is retried, while
+	// delivery 3 fails for the last time. Another dispatcher got to
+	// delivery 4 first.
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]model.DueDelivery) = []model.DueDelivery{
+				due(server.URL, 1, 0), due(server.URL, 2, 0), due(server.URL, 3, 2), due(server.URL, 4, 0),
+			}
+			return nil
+		}).Once()
+	for _, id := range []int64{1, 2, 3} {
+		expectExec(mockDB, "SET next_attempt_at = ?", 1, id)
+	}
+	mockDB.EXPECT().
+		ExecContext(mock.Anything, mock.MatchedBy(func(q string) bool {
+			return strings.Contains(q, "SET next_attempt_at = ?")
+		}), mock.MatchedBy(func(args []interface{}) bool {
+			return args[1] == int64(4)
+		})).
+		Return(driver.RowsAffected(0), nil).Once()
+
+	expectExec(mockDB, "SET status = 'delivered'", 2, int64(1))
+	expectExec(mockDB, "SET status = ?", 0, model.DeliveryPending).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, 1, args[1])
+			assert.WithinDuration(t, time.Now().Add(30*time.Second), args[2].(time.Time), 5*time.Second)
+		})
+	expectExec(mockDB, "SET status = ?", 0, model.DeliveryFailed).
+		Run(func(ctx context.Context, query string, args ...interface{}) {
+			assert.Equal(t, 3, args[1])
+			assert.Equal(t, "503 Service Unavailable", args[4])
+		})
+	expectExec(mockDB, "INSERT INTO webhook_dead_letters", 0, int64(3))
+
+	config := model.WebhookConfig{Interval: time.Second, MaxAttempts: 3, Timeout: time.Second}
+	sent, err := webhook.NewDispatcher(mockDB, config).Dispatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, []string{"1"}, rc.accepted)
+}
+
+func TestDispatch_LeaseFromClaim(t *testing.T) {
+	rc := &receiver{t: t, delay: 300 * time.Millisecond}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	config := model.WebhookConfig{Interval: time.Second, MaxAttempts: 3, Timeout: time.Second}
+	mockDB := model.NewMockDBTX(t)
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+			*dest.(*[]model.DueDelivery) = []model.DueDelivery{due(server.URL, 1, 0), due(server.URL, 2, 0)}
+			return nil
+		}).Once()
+	// Delivery 2 is claimed after delivery 1 took its time, and still gets
+	// the whole lease.
+	for _, id := range []int64{1, 2} {
+		expectExec(mockDB, "SET next_attempt_at = ?", 1, id).
+			Run(func(ctx context.Context, query string, args ...interface{}) {
+				assert.WithinDuration(t, time.Now().Add(config.Timeout+time.Minute), args[0].(time.Time), 100*time.Millisecond)
+			})
+		expectExec(mockDB, "SET status = 'delivered'", 2, id)
+	}
+
+	sent, err := webhook.NewDispatcher(mockDB, config).Dispatch(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+}
+
+func TestBackoff(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		5:  8 * time.Minute,
+		20: 6 * time.Hour,
+	} {
+		assert.Equal(t, want, webhook.Backoff(attempts), "after %d attempts", attempts)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	at := time.Unix(1772463600, 0)
+	body := []byte(`{"event":"task.created"}`)
+	signature := webhook.Sign(secret, at, body)
+
+	assert.True(t, strings.HasPrefix(signature, webhook.SignaturePrefix))
+	assert.True(t, webhook.Verify(secret, at, body, signature))
+	assert.False(t, webhook.Verify("whsec_other", at, body, signature))
+	assert.False(t, webhook.Verify(secret, at.Add(time.Second), body, signature))
+}
+
+func TestDispatcherStop(t *testing.T) {
+	mockDB := model.NewMockDBTX(t)
+	// The first run may or may not have started by the time Stop is called.
+	mockDB.EXPECT().
+		SelectContext(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil).Maybe()
+
+	dispatcher := webhook.NewDispatcher(mockDB, model.WebhookConfig{Interval: time.Hour, MaxAttempts: 1, Timeout: time.Second})
+	dispatcher.Start(context.Background())
+
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+}
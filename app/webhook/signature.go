@@ -0,0 +1,29 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// SignaturePrefix starts the X-Webhook-Signature header.
+const SignaturePrefix = "sha256="
+
+// Sign is the X-Webhook-Signature of a body sent at the given time: the
+// hex HMAC-SHA256, keyed with the webhook secret, of the X-Webhook-Timestamp
+// value, a dot and the body.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the one Sign makes, comparing in
+// constant time.
+func Verify(secret string, timestamp time.Time, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
@@ -2,5 +2,20 @@ DROP DATABASE IF EXISTS tasking;
 CREATE DATABASE tasking;
 USE tasking;
 
+-- In the reverse of the order tasking/ creates them in.
+DROP TABLE IF EXISTS project_members;
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS webhook_dead_letters;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS category_permissions;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS task_reminders;
+DROP TABLE IF EXISTS task_dependencies;
+DROP TABLE IF EXISTS task_events;
+DROP TABLE IF EXISTS task_tags;
+DROP TABLE IF EXISTS tags;
 DROP TABLE IF EXISTS tasks;
-DROP TABLE IF EXISTS categories;
\ No newline at end of file
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS projects;
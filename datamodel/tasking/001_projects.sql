@@ -0,0 +1,7 @@
+-- Projects own tasks and categories. Nothing links across two of them, and
+-- deleting a project deletes everything in it.
+CREATE TABLE tasking.projects (
+  id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  name        VARCHAR(100) NOT NULL UNIQUE,
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
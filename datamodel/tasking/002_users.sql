@@ -0,0 +1,9 @@
+-- People tasks are assigned to and reported by. A bearer token names one of
+-- them by id or email.
+CREATE TABLE tasking.users (
+  id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  name        VARCHAR(100) NOT NULL,
+  email       VARCHAR(255) NOT NULL UNIQUE,
+  role        ENUM('viewer','member','admin') NOT NULL DEFAULT 'member',
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB;
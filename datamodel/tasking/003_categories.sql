@@ -0,0 +1,19 @@
+-- Categories, nested through parent_id. path is the full name from the root
+-- ("Backend/Auth") and is kept in step with name and parent_id on writes.
+-- Each project has its own tree, so a path is only unique within a project;
+-- it is 760 characters wide so that the pair still fits in an index.
+CREATE TABLE tasking.categories (
+  id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  project_id  BIGINT UNSIGNED NOT NULL,
+  name        VARCHAR(100) NOT NULL,
+  parent_id   BIGINT UNSIGNED NULL,
+  path        VARCHAR(760) NOT NULL,
+
+  UNIQUE KEY uniq_project_path (project_id, path),
+  KEY idx_parent (parent_id),
+
+  CONSTRAINT fk_category_project
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+  CONSTRAINT fk_category_parent
+    FOREIGN KEY (parent_id) REFERENCES categories(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
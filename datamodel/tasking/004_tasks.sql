@@ -0,0 +1,43 @@
+-- Tasks with parent/child hierarchy
+CREATE TABLE tasking.tasks (
+  id              BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  project_id      BIGINT UNSIGNED NOT NULL,
+  title           VARCHAR(255) NOT NULL,
+  description     TEXT,
+  status          ENUM('todo','in_progress','done') NOT NULL DEFAULT 'todo',
+  priority        TINYINT NOT NULL DEFAULT 0,
+  due_date        DATE NULL,
+  estimate_hours  DECIMAL(7,2) NULL,
+  recurrence      VARCHAR(255) NULL,
+  recur_subtasks  BOOLEAN NOT NULL DEFAULT FALSE,
+  completed_at    DATETIME NULL,
+  parent_task_id  BIGINT UNSIGNED NULL,
+  position        INT NOT NULL DEFAULT 0,
+  category_id     BIGINT UNSIGNED NULL,
+  assignee_id     BIGINT UNSIGNED NULL,
+  reporter_id     BIGINT UNSIGNED NULL,
+  created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  deleted_at      DATETIME NULL,
+  -- The task whose deletion put this one in the trash, which restoring
+  -- that task brings back.
+  deleted_root_id BIGINT UNSIGNED NULL,
+
+  KEY idx_parent (parent_task_id, position),
+  KEY idx_category (category_id),
+  KEY idx_assignee (assignee_id),
+  KEY idx_reporter (reporter_id),
+  KEY idx_project_priority_created (project_id, priority, created_at, id),
+  KEY idx_deleted (deleted_at),
+
+  CONSTRAINT fk_task_project
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+  CONSTRAINT fk_task_parent
+    FOREIGN KEY (parent_task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+  CONSTRAINT fk_task_category
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL,
+  CONSTRAINT fk_task_assignee
+    FOREIGN KEY (assignee_id) REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT fk_task_reporter
+    FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE SET NULL
+) ENGINE=InnoDB;
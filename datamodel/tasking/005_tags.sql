@@ -0,0 +1,5 @@
+-- Free-form labels. Names are stored trimmed and lower-cased.
+CREATE TABLE tasking.tags (
+  id    BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  name  VARCHAR(50) NOT NULL UNIQUE
+) ENGINE=InnoDB;
@@ -0,0 +1,13 @@
+-- The tags of each task.
+CREATE TABLE tasking.task_tags (
+  task_id  BIGINT UNSIGNED NOT NULL,
+  tag_id   BIGINT UNSIGNED NOT NULL,
+
+  PRIMARY KEY (task_id, tag_id),
+  KEY idx_tag (tag_id, task_id),
+
+  CONSTRAINT fk_task_tags_task
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+  CONSTRAINT fk_task_tags_tag
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,16 @@
+-- Change history of tasks. There is no foreign key on task_id so that the
+-- history of a purged task is kept; it goes with the project.
+CREATE TABLE tasking.task_events (
+  id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  project_id  BIGINT UNSIGNED NOT NULL,
+  task_id     BIGINT UNSIGNED NOT NULL,
+  action      ENUM('create','update','move','delete','restore') NOT NULL,
+  actor       VARCHAR(255) NULL,
+  changes     JSON NOT NULL,
+  created_at  DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+
+  KEY idx_task_created (task_id, created_at),
+
+  CONSTRAINT fk_task_event_project
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,16 @@
+-- "blocker_id blocks blocked_id": the blocked task cannot start or finish
+-- until the blocker is done. Both tasks are in the same project and the
+-- edges form a DAG, which the application keeps that way.
+CREATE TABLE tasking.task_dependencies (
+  blocker_id  BIGINT UNSIGNED NOT NULL,
+  blocked_id  BIGINT UNSIGNED NOT NULL,
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (blocker_id, blocked_id),
+  KEY idx_blocked (blocked_id, blocker_id),
+
+  CONSTRAINT fk_task_dependency_blocker
+    FOREIGN KEY (blocker_id) REFERENCES tasks(id) ON DELETE CASCADE,
+  CONSTRAINT fk_task_dependency_blocked
+    FOREIGN KEY (blocked_id) REFERENCES tasks(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,14 @@
+-- One row per reminder that went out. A reminder is a lead time before the
+-- end of the due date, or "overdue" once it has passed; moving the due date
+-- starts a fresh set.
+CREATE TABLE tasking.task_reminders (
+  task_id     BIGINT UNSIGNED NOT NULL,
+  due_date    DATE NOT NULL,
+  reminder    VARCHAR(32) NOT NULL,
+  fired_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (task_id, due_date, reminder),
+
+  CONSTRAINT fk_task_reminder_task
+    FOREIGN KEY (task_id) REFERENCES tasks(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
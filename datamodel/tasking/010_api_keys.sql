@@ -0,0 +1,17 @@
+-- API keys. Only the SHA-256 of a key is stored; prefix keeps its first
+-- characters so that its owner can tell keys apart.
+CREATE TABLE tasking.api_keys (
+  id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  user_id     BIGINT UNSIGNED NOT NULL,
+  name        VARCHAR(100) NOT NULL,
+  prefix      CHAR(12) NOT NULL,
+  key_hash    CHAR(64) NOT NULL UNIQUE,
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at  DATETIME NULL,
+  revoked_at  DATETIME NULL,
+
+  KEY idx_user (user_id),
+
+  CONSTRAINT fk_api_key_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
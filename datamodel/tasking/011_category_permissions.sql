@@ -0,0 +1,15 @@
+-- Roles granted to a user on a category and everything below it, on top of
+-- the user's own role.
+CREATE TABLE tasking.category_permissions (
+  user_id      BIGINT UNSIGNED NOT NULL,
+  category_id  BIGINT UNSIGNED NOT NULL,
+  role         ENUM('viewer','member','admin') NOT NULL,
+
+  PRIMARY KEY (user_id, category_id),
+  KEY idx_category (category_id),
+
+  CONSTRAINT fk_permission_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_permission_category
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
@@ -0,0 +1,15 @@
+-- Webhook subscriptions of a project. events lists the task events the hook
+-- receives. The secret signs every delivery, so it is kept in clear.
+CREATE TABLE tasking.webhooks (
+  id          BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  project_id  BIGINT UNSIGNED NOT NULL,
+  url         VARCHAR(2048) NOT NULL,
+  secret      VARCHAR(128) NOT NULL,
+  events      SET('task.created','task.updated','task.completed','task.deleted','task.moved') NOT NULL,
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  KEY idx_project (project_id),
+
+  CONSTRAINT fk_webhook_project
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
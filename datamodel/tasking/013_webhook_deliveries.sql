@@ -0,0 +1,22 @@
+-- One row per event sent to a webhook. A delivery is queued when the event
+-- is published from the outbox and sent later; a failed attempt is retried
+-- at next_attempt_at until the attempts run out.
+CREATE TABLE tasking.webhook_deliveries (
+  id               BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  webhook_id       BIGINT UNSIGNED NOT NULL,
+  event            VARCHAR(32) NOT NULL,
+  payload          JSON NOT NULL,
+  status           ENUM('pending','delivered','failed') NOT NULL DEFAULT 'pending',
+  attempts         INT NOT NULL DEFAULT 0,
+  next_attempt_at  DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  response_status  INT NULL,
+  last_error       VARCHAR(255) NULL,
+  created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at     DATETIME NULL,
+
+  KEY idx_due (status, next_attempt_at),
+  KEY idx_webhook (webhook_id, id),
+
+  CONSTRAINT fk_webhook_delivery_webhook
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
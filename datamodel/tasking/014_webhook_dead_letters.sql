@@ -0,0 +1,16 @@
+-- Deliveries that ran out of attempts, kept for inspection and replay.
+CREATE TABLE tasking.webhook_dead_letters (
+  delivery_id      BIGINT UNSIGNED PRIMARY KEY,
+  webhook_id       BIGINT UNSIGNED NOT NULL,
+  event            VARCHAR(32) NOT NULL,
+  payload          JSON NOT NULL,
+  attempts         INT NOT NULL,
+  response_status  INT NULL,
+  last_error       VARCHAR(255) NULL,
+  created_at       TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  KEY idx_webhook (webhook_id),
+
+  CONSTRAINT fk_webhook_dead_letter_delivery
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
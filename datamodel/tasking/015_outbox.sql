@@ -0,0 +1,21 @@
+-- Task events waiting to be published. An event is written in the same
+-- transaction as the change it describes, and published_at is set once
+-- every sink has it. A dispatcher claims an event by moving next_attempt_at
+-- past the attempt, and a failed attempt is retried at next_attempt_at.
+-- There are no foreign keys so that an event outlives its task, and even
+-- its project, until it is out.
+CREATE TABLE tasking.outbox (
+  id               BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
+  project_id       BIGINT UNSIGNED NOT NULL,
+  task_id          BIGINT UNSIGNED NOT NULL,
+  event            VARCHAR(32) NOT NULL,
+  payload          JSON NOT NULL,
+  attempts         INT NOT NULL DEFAULT 0,
+  last_error       VARCHAR(255) NULL,
+  next_attempt_at  DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  created_at       DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  published_at     DATETIME(6) NULL,
+
+  KEY idx_unpublished (published_at, id),
+  KEY idx_task (task_id, id)
+) ENGINE=InnoDB;
@@ -0,0 +1,15 @@
+-- Users let into a project. Admins are let into every project without being
+-- listed here.
+CREATE TABLE tasking.project_members (
+  project_id   BIGINT UNSIGNED NOT NULL,
+  user_id      BIGINT UNSIGNED NOT NULL,
+  created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+  PRIMARY KEY (project_id, user_id),
+  KEY idx_user (user_id),
+
+  CONSTRAINT fk_member_project
+    FOREIGN KEY (project_id) REFERENCES projects(id) ON DELETE CASCADE,
+  CONSTRAINT fk_member_user
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB;
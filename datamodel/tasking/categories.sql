@@ -1,5 +0,0 @@
--- Categories 
-CREATE TABLE tasking.categories (
-  id   BIGINT UNSIGNED PRIMARY KEY AUTO_INCREMENT,
-  name VARCHAR(100) NOT NULL UNIQUE
-) ENGINE=InnoDB;
@@ -0,0 +1,5 @@
+-- Users, first because tasks are assigned to and reported by them
+INSERT INTO tasking.users (name, email, role) VALUES
+  ('Alice Martin', 'alice@example.com', 'admin'),
+  ('Bruno Petit', 'bruno@example.com', 'member'),
+  ('Chloé Durand', 'chloe@example.com', 'viewer');
@@ -0,0 +1,8 @@
+-- Projects, first because categories and tasks belong to one
+INSERT INTO tasking.projects (name) VALUES
+  ('Platform'), ('Mobile');
+
+-- Categories
+INSERT INTO tasking.categories (project_id, name, path) VALUES
+  (1, 'Backend', 'Backend'), (1, 'Frontend', 'Frontend'), (1, 'Bug', 'Bug'), (1, 'Feature', 'Feature'),
+  (2, 'Bug', 'Bug');
@@ -0,0 +1,32 @@
+-- Root tasks, all reported by Alice
+INSERT INTO tasking.tasks (project_id, title, status, priority, due_date, estimate_hours, category_id, assignee_id, reporter_id)
+VALUES
+  (1, 'Implémenter API Auth', 'in_progress', 3, '2025-08-20', 4, 1, 1, 1),
+  (1, 'Créer Dashboard UI', 'todo', 2, '2025-08-25', 16, 2, 2, 1),
+  (1, 'Corriger bug login', 'todo', 4, '2025-08-15', 2, 3, NULL, 1);
+
+-- Subtasks for task 1
+INSERT INTO tasks (project_id, title, status, priority, due_date, estimate_hours, parent_task_id, category_id, assignee_id, reporter_id)
+VALUES
+  (1, 'Ajouter OAuth2', 'todo', 2, '2025-08-18', 8, 1, 1, 1, 1),
+  (1, 'Configurer JWT', 'todo', 3, '2025-08-19', 6, 1, 1, 1, 1);
+
+-- Subtasks for task 2
+INSERT INTO tasks (project_id, title, status, priority, due_date, estimate_hours, parent_task_id, category_id, assignee_id, reporter_id)
+VALUES
+  (1, 'Créer composant Graphique', 'in_progress', 2, '2025-08-22', 12, 2, 2, 2, 1);
+
+-- The Mobile project
+INSERT INTO tasks (project_id, title, status, priority, due_date, category_id, reporter_id)
+VALUES
+  (2, 'Corriger crash au démarrage', 'todo', 4, '2025-08-16', 5, 1);
+
+-- A weekly chore
+INSERT INTO tasks (project_id, title, status, priority, due_date, estimate_hours, recurrence, category_id, reporter_id)
+VALUES
+  (1, 'Revue hebdomadaire', 'todo', 1, '2025-08-18', 1, 'FREQ=WEEKLY;BYDAY=MO', 2, 1);
+
+-- OAuth2 waits for JWT
+INSERT INTO task_dependencies (blocker_id, blocked_id)
+VALUES
+  (5, 4);
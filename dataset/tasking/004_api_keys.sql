@@ -0,0 +1,3 @@
+-- Local development key for alice: gtk_local-dev-key-alice
+INSERT INTO tasking.api_keys (user_id, name, prefix, key_hash) VALUES
+  (1, 'local development', 'gtk_local-de', '8bbc91d3a81ddbfb7e2f7f799770488db29d300c19d63ca624e25b6ed0708a87');
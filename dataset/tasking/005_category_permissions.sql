@@ -0,0 +1,3 @@
+-- Chloé can read everything and work on Frontend tasks
+INSERT INTO tasking.category_permissions (user_id, category_id, role) VALUES
+  (3, 2, 'member');
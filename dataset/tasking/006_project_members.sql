@@ -0,0 +1,6 @@
+-- Alice is an admin and sees every project. Bruno works on both, Chloé only
+-- on Platform.
+INSERT INTO tasking.project_members (project_id, user_id) VALUES
+  (1, 2),
+  (2, 2),
+  (1, 3);
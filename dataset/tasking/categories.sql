@@ -1,3 +0,0 @@
--- Categories
-INSERT INTO tasking.categories (name) VALUES
-  ('Backend'), ('Frontend'), ('Bug'), ('Feature');
\ No newline at end of file